@@ -0,0 +1,350 @@
+package arg
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Types of flag values.
+const (
+	FlagBool   = "bool"
+	FlagString = "string"
+	FlagInt    = "int"
+)
+
+// A command-line flag: its names and description, shown in
+// help screens, and how its value is parsed by ParseFlags.
+type Flag struct {
+	Name string // The flag names, e.g. "-k, --key".
+	Desc string // The flag description.
+
+	Type     string   // The type of the flag's value, FlagBool if empty.
+	Enum     []string // The values the flag may have, if not empty.
+	Default  string   // The value of the flag if it is not given.
+	Env      string   // A variable whose value is used if the flag is not given.
+	Required bool     // Must the flag be given, or Env be set?
+
+	// May the flag be given more than once? Its values are
+	// then collected in a slice.
+	Repeat bool
+}
+
+// Returns the flag's names, e.g. ["-k", "--key"].
+func (f *Flag) Names() []string {
+	names := strings.Split(f.Name, ",")
+
+	for i, n := range names {
+		names[i] = strings.TrimSpace(n)
+	}
+
+	return names
+}
+
+// Returns the key of the flag's value in the struct or map
+// that ParseFlags fills: its first long name without dashes,
+// or else its first short name without dash, e.g. "key".
+func (f *Flag) Key() string {
+	names := f.Names()
+
+	for _, n := range names {
+		if strings.HasPrefix(n, "--") {
+			return n[2:]
+		}
+	}
+
+	return strings.TrimPrefix(names[0], "-")
+}
+
+// Does the flag take a value?
+func (f *Flag) HasValue() bool {
+	return Ok(f.Type) && f.Type != FlagBool
+}
+
+// Parse the flags at the head of args, up to the first
+// argument that is not a flag or "--", and store their values
+// in v, which must be a pointer to a struct or a
+// map[string]interface{}. Returns the rest of args.
+//
+// The value of each flag is stored as a bool, string or int,
+// or as a slice of them if the flag may be repeated. In a
+// struct, it is stored in the field whose "flag" tag is the
+// flag's key (see Flag.Key), e.g.:
+//
+//	var o struct {
+//		Key string `flag:"key"`
+//	}
+//
+// Flags that are not given, and have no default or variable,
+// are not stored. It is an error if a flag is unknown, or if
+// a struct has no exported field of the right type for a flag.
+func ParseFlags(flags []*Flag, args []string, v interface{}) ([]string, error) {
+	return parseFlags(flags, args, v, false, false)
+}
+
+// Like ParseFlags, but stops at the first unknown flag
+// instead, so that the rest of args can be passed on.
+func ParseKnownFlags(flags []*Flag, args []string, v interface{}) ([]string, error) {
+	return parseFlags(flags, args, v, true, false)
+}
+
+// Like ParseFlags, but flags may be given before or after the
+// other arguments, up to "--". Returns the other arguments in
+// order, followed by those after "--".
+func ParseFlagsAndArgs(flags []*Flag, args []string, v interface{}) ([]string, error) {
+	return parseFlags(flags, args, v, false, true)
+}
+
+func parseFlags(flags []*Flag, args []string, v interface{}, known, mixed bool) ([]string, error) {
+	if err := checkFlagValues(flags, v); err != nil {
+		return nil, err
+	}
+
+	var (
+		vals  = make(map[*Flag][]string)
+		other []string
+	)
+
+	for len(args) > 0 {
+		if !IsAFlag(args[0]) {
+			if !mixed {
+				break
+			}
+
+			other, args = append(other, args[0]), args[1:]
+			continue
+		}
+
+		if args[0] == "--" {
+			args = args[1:]
+			break
+		}
+
+		var (
+			a       = args[0]
+			names   = []string{a}
+			val     string
+			hasVal  bool
+			unknown string
+		)
+
+		if i := strings.IndexByte(a, '='); strings.HasPrefix(a, "--") && i > 0 {
+			names[0], val, hasVal = a[:i], a[i+1:], true
+		} else if !strings.HasPrefix(a, "--") && len(a) > 2 {
+			// combined short -abc flags
+			names = names[:0]
+
+			for _, s := range SplitFlags(a) {
+				names = append(names, "-"+s)
+			}
+		}
+
+		fs := make([]*Flag, len(names))
+
+		for i, n := range names {
+			if fs[i] = findFlag(flags, n); fs[i] == nil {
+				unknown = n
+				break
+			}
+		}
+
+		if Ok(unknown) && known {
+			break
+		} else if Ok(unknown) {
+			return nil, FlagUnknownError(unknown)
+		}
+
+		args = args[1:]
+
+		for i, f := range fs {
+			n := names[i]
+
+			if !f.HasValue() {
+				if !hasVal {
+					vals[f] = append(vals[f], "true")
+					continue
+				}
+			} else if !hasVal {
+				if i < len(fs)-1 || len(args) == 0 || IsAFlag(args[0]) {
+					return nil, ArgRequiredError(n)
+				}
+
+				val, args = args[0], args[1:]
+			}
+
+			if len(vals[f]) > 0 && !f.Repeat {
+				return nil, FlagError("is given more than once", n)
+			}
+
+			vals[f] = append(vals[f], val)
+		}
+	}
+
+	for _, f := range flags {
+		if err := setFlag(f, vals[f], v); err != nil {
+			return nil, err
+		}
+	}
+
+	if mixed {
+		return append(other, args...), nil
+	}
+
+	return args, nil
+}
+
+// Returns the flag named n, or nil if there is none.
+func findFlag(flags []*Flag, n string) *Flag {
+	for _, f := range flags {
+		if IsWord(n, f.Names()...) {
+			return f
+		}
+	}
+	return nil
+}
+
+// Parse the raw values of flag f, or its variable or default
+// if there are none, and store them in v.
+func setFlag(f *Flag, raw []string, v interface{}) error {
+	if len(raw) == 0 {
+		if env := os.Getenv(f.Env); Ok(f.Env) && Ok(env) {
+			raw = []string{env}
+		} else if Ok(f.Default) {
+			raw = []string{f.Default}
+		} else if f.Required {
+			return FlagRequiredError(f.Name)
+		} else {
+			return nil
+		}
+	}
+
+	vals := make([]interface{}, len(raw))
+
+	for i, r := range raw {
+		val, err := parseFlagValue(f, r)
+
+		if err != nil {
+			return err
+		}
+
+		vals[i] = val
+	}
+
+	if !f.Repeat {
+		storeFlag(v, f.Key(), vals[0])
+		return nil
+	}
+
+	slice := reflect.MakeSlice(f.valueType(), 0, len(vals))
+
+	for _, val := range vals {
+		slice = reflect.Append(slice, reflect.ValueOf(val))
+	}
+
+	storeFlag(v, f.Key(), slice.Interface())
+
+	return nil
+}
+
+// Returns raw value r of flag f, as the flag's type.
+func parseFlagValue(f *Flag, r string) (interface{}, error) {
+	if len(f.Enum) > 0 && !IsWord(r, f.Enum...) {
+		return nil, FlagError(fmt.Sprintf("must be: %s", strings.Join(f.Enum, ", ")), f.Name)
+	}
+
+	switch f.Type {
+	case "", FlagBool:
+		b, err := strconv.ParseBool(r)
+
+		if err != nil {
+			return nil, FlagError("must be true or false", f.Name)
+		}
+
+		return b, nil
+	case FlagInt:
+		n, err := strconv.Atoi(r)
+
+		if err != nil {
+			return nil, FlagError("must be an integer", f.Name)
+		}
+
+		return n, nil
+	case FlagString:
+		return r, nil
+	}
+
+	panic(fmt.Sprintf("Unknown type of the %s flag: %s", f.Name, f.Type))
+}
+
+// Returns the type of the flag's values, as they are stored
+// by ParseFlags.
+func (f *Flag) valueType() reflect.Type {
+	var t reflect.Type
+
+	switch f.Type {
+	case FlagInt:
+		t = reflect.TypeOf(0)
+	case FlagString:
+		t = reflect.TypeOf("")
+	default:
+		t = reflect.TypeOf(false)
+	}
+
+	if f.Repeat {
+		t = reflect.SliceOf(t)
+	}
+
+	return t
+}
+
+// Check that the values of flags can be stored in v, a
+// map[string]interface{} or a pointer to a struct that has an
+// exported field of the right type for each flag.
+func checkFlagValues(flags []*Flag, v interface{}) error {
+	if _, ok := v.(map[string]interface{}); ok {
+		return nil
+	}
+
+	rv := reflect.ValueOf(v)
+
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Flags must be parsed into a pointer to a struct or a map[string]interface{}, not %T", v)
+	}
+
+	for _, f := range flags {
+		field, ok := flagField(rv.Elem(), f.Key())
+
+		if !ok {
+			return fmt.Errorf("No field is tagged for the %s flag: flag:%q", f.Name, f.Key())
+		} else if t := f.valueType(); !field.CanSet() || field.Type() != t {
+			return fmt.Errorf("The field of the %s flag must be an exported %s", f.Name, t)
+		}
+	}
+
+	return nil
+}
+
+// Returns the field of struct s whose "flag" tag is k.
+func flagField(s reflect.Value, k string) (reflect.Value, bool) {
+	for i := 0; i < s.NumField(); i++ {
+		if s.Type().Field(i).Tag.Get("flag") == k {
+			return s.Field(i), true
+		}
+	}
+
+	return reflect.Value{}, false
+}
+
+// Store value val with key k in v, which checkFlagValues
+// accepts.
+func storeFlag(v interface{}, k string, val interface{}) {
+	if m, ok := v.(map[string]interface{}); ok {
+		m[k] = val
+		return
+	}
+
+	field, _ := flagField(reflect.ValueOf(v).Elem(), k)
+	field.Set(reflect.ValueOf(val))
+}
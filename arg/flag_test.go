@@ -0,0 +1,144 @@
+package arg
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+var testFlags = []*Flag{
+	{Name: "-v, --verbose"},
+	{Name: "-q"},
+	{Name: "-o, --out", Type: FlagString, Enum: []string{"json", "yaml"}, Default: "json"},
+	{Name: "-n", Type: FlagInt, Env: "TEST_FLAG_N"},
+	{Name: "-I, --include", Type: FlagString, Repeat: true},
+}
+
+func TestParseFlags(t *testing.T) {
+	t.Setenv("TEST_FLAG_N", "")
+
+	tests := []struct {
+		args []string
+		vals map[string]interface{}
+		rest []string
+		err  string
+	}{
+		{
+			args: []string{"a", "-v"},
+			vals: map[string]interface{}{"out": "json"},
+			rest: []string{"a", "-v"},
+		},
+		{
+			args: []string{"-vq", "--out", "yaml", "-n", "3", "a"},
+			vals: map[string]interface{}{"verbose": true, "q": true, "out": "yaml", "n": 3},
+			rest: []string{"a"},
+		},
+		{
+			args: []string{"--out=yaml", "-I", "a", "--include", "b", "--", "-v"},
+			vals: map[string]interface{}{"out": "yaml", "include": []string{"a", "b"}},
+			rest: []string{"-v"},
+		},
+		{
+			args: []string{"--verbose=false"},
+			vals: map[string]interface{}{"verbose": false, "out": "json"},
+			rest: []string{},
+		},
+		{args: []string{"-x"}, err: "The -x flag is unknown"},
+		{args: []string{"-vx"}, err: "The -x flag is unknown"},
+		{args: []string{"-o"}, err: "The -o argument is required"},
+		{args: []string{"-o", "-v"}, err: "The -o argument is required"},
+		{args: []string{"-on", "1"}, err: "The -o argument is required"},
+		{args: []string{"-o", "xml"}, err: "The -o, --out flag must be: json, yaml"},
+		{args: []string{"-n", "x"}, err: "The -n flag must be an integer"},
+		{args: []string{"-o", "json", "-o", "yaml"}, err: "The -o flag is given more than once"},
+	}
+
+	for _, test := range tests {
+		vals := make(map[string]interface{})
+		rest, err := ParseFlags(testFlags, test.args, vals)
+
+		if test.err != "" {
+			assert.Error(t, err, test.err, test.args)
+			continue
+		}
+
+		assert.NilError(t, err, test.args)
+		assert.DeepEqual(t, vals, test.vals)
+		assert.DeepEqual(t, rest, test.rest)
+	}
+}
+
+func TestParseFlagsStruct(t *testing.T) {
+	t.Setenv("TEST_FLAG_N", "7")
+
+	var o struct {
+		Verbose bool     `flag:"verbose"`
+		Q       bool     `flag:"q"`
+		Out     string   `flag:"out"`
+		N       int      `flag:"n"`
+		Include []string `flag:"include"`
+	}
+
+	rest, err := ParseFlags(testFlags, []string{"-v", "-I", "a", "b"}, &o)
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, rest, []string{"b"})
+	assert.Equal(t, o.Verbose, true)
+	assert.Equal(t, o.Out, "json")
+	assert.Equal(t, o.N, 7)
+	assert.DeepEqual(t, o.Include, []string{"a"})
+
+	var r struct {
+		R string `flag:"r"`
+	}
+
+	required := []*Flag{{Name: "-r", Type: FlagString, Required: true}}
+
+	_, err = ParseFlags(required, nil, &r)
+	assert.Error(t, err, "The -r flag is required")
+
+	_, err = ParseFlags(required, []string{"-r", "a"}, &o)
+	assert.Error(t, err, `No field is tagged for the -r flag: flag:"r"`)
+
+	var mismatch struct {
+		N string `flag:"n"`
+	}
+
+	_, err = ParseFlags([]*Flag{{Name: "-n", Type: FlagInt}}, nil, &mismatch)
+	assert.Error(t, err, "The field of the -n flag must be an exported int")
+
+	var unexported struct {
+		n int `flag:"n"`
+	}
+
+	_, err = ParseFlags([]*Flag{{Name: "-n", Type: FlagInt}}, nil, &unexported)
+	assert.Error(t, err, "The field of the -n flag must be an exported int")
+
+	_, err = ParseFlags(testFlags, nil, o)
+	assert.ErrorContains(t, err, "Flags must be parsed into a pointer to a struct")
+}
+
+func TestParseKnownFlags(t *testing.T) {
+	vals := make(map[string]interface{})
+	rest, err := ParseKnownFlags(testFlags, []string{"-q", "--no-input", "-v", "a"}, vals)
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, rest, []string{"--no-input", "-v", "a"})
+	assert.DeepEqual(t, vals, map[string]interface{}{"q": true, "out": "json"})
+}
+
+func TestParseFlagsAndArgs(t *testing.T) {
+	vals := make(map[string]interface{})
+	rest, err := ParseFlagsAndArgs(testFlags, []string{"-I", "x", "a", "-v", "b", "--include=y", "--", "-q"}, vals)
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, rest, []string{"a", "b", "-q"})
+	assert.DeepEqual(t, vals, map[string]interface{}{
+		"include": []string{"x", "y"},
+		"verbose": true,
+		"out":     "json",
+	})
+
+	_, err = ParseFlagsAndArgs(testFlags, []string{"a", "-x"}, vals)
+	assert.Error(t, err, "The -x flag is unknown")
+}
@@ -0,0 +1,256 @@
+// Provides utilities to find blanks in BLANK_PATH directories.
+package blk
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// The interpreter of shell scripts without a "#!" line.
+const DefaultShell = "sh"
+
+// Blank engines, i.e. the kinds of files blanks are run from.
+const (
+	EngineMake  = "make" // a makefile, "[target].mk"
+	EngineShell = "sh"   // a shell script, "[target].sh"
+	EngineExec  = "exec" // an executable file, "[target]"
+
+	// A recipe of steps, "[target].blank.yaml" (see Recipe).
+	EngineRecipe = "recipe"
+
+	// A directory, "[target]/", whose file tree is rendered
+	// into the project (see the tpl package).
+	EngineTemplate = "template"
+)
+
+// The engines and the file extensions of their blanks, in
+// order of precedence. A path without extension is either an
+// executable or a template directory.
+var engines = []struct {
+	Engine string
+	Ext    string
+}{
+	{EngineMake, ".mk"},
+	{EngineShell, ".sh"},
+	{EngineRecipe, ".blank.yaml"},
+	{EngineExec, ""},
+}
+
+// A blank found in a BLANK_PATH directory.
+type Blank struct {
+	Name   string // The target name.
+	Dir    string // The directory it was found in.
+	Path   string // The path to its file.
+	Engine string // One of the Engine* constants.
+	Desc   string // A one-line description.
+	Hidden bool   // Is it hidden by a blank of the same name found earlier?
+}
+
+// List the blanks in the given directories, in order.
+// Directories that do not exist are ignored.
+func List(dirs []string) ([]*Blank, error) {
+	var (
+		list []*Blank
+		seen = make(map[string]bool)
+	)
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		names := make(map[string]bool)
+
+		for _, e := range entries {
+			if name, ok := blankName(e.Name()); ok {
+				names[name] = true
+			}
+		}
+
+		for _, name := range sortedNames(names) {
+			for _, c := range candidates(dir, name) {
+				if !c.Found {
+					continue
+				}
+
+				b := newBlank(name, dir, c)
+				b.Hidden = seen[name]
+				seen[name] = true
+
+				list = append(list, b)
+			}
+		}
+	}
+
+	return list, nil
+}
+
+// Returns the name of the blank that a file may be.
+func blankName(file string) (string, bool) {
+	if strings.HasPrefix(file, ".") {
+		return "", false
+	}
+
+	for _, e := range engines {
+		if e.Ext != "" && strings.HasSuffix(file, e.Ext) {
+			return strings.TrimSuffix(file, e.Ext), true
+		}
+	}
+
+	return file, filepath.Ext(file) == ""
+}
+
+func sortedNames(m map[string]bool) []string {
+	names := make([]string, 0, len(m))
+
+	for n := range m {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Clean a list of directories, such as BLANK_PATH: empty
+// entries are removed, a leading "~" is expanded to the
+// user's home directory, and paths are made absolute.
+func CleanPaths(dirs []string) []string {
+	clean := make([]string, 0, len(dirs))
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		if dir == "~" || strings.HasPrefix(dir, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				dir = filepath.Join(home, dir[1:])
+			}
+		}
+
+		if abs, err := filepath.Abs(dir); err == nil {
+			dir = abs
+		}
+
+		clean = append(clean, dir)
+	}
+
+	return clean
+}
+
+// A path checked when looking for a blank. The engine of a
+// missing path without extension is EngineExec.
+type Candidate struct {
+	Path   string
+	Engine string
+	Found  bool
+}
+
+// Returns the paths of a blank in dir, for all engines, in
+// order of precedence.
+func candidates(dir, name string) []*Candidate {
+	cands := make([]*Candidate, len(engines))
+
+	for i, e := range engines {
+		c := &Candidate{
+			Path:   filepath.Join(dir, name+e.Ext),
+			Engine: e.Engine,
+		}
+
+		info, err := os.Stat(c.Path)
+
+		switch {
+		case err != nil:
+		case info.Mode().IsRegular():
+			c.Found = e.Engine != EngineExec || info.Mode()&0111 != 0
+		case info.IsDir() && e.Engine == EngineExec:
+			c.Engine = EngineTemplate
+			c.Found = true
+		}
+
+		cands[i] = c
+	}
+
+	return cands
+}
+
+func newBlank(name, dir string, c *Candidate) *Blank {
+	b := &Blank{
+		Name:   name,
+		Dir:    dir,
+		Path:   c.Path,
+		Engine: c.Engine,
+	}
+
+	if p, err := filepath.EvalSymlinks(c.Path); err == nil {
+		b.Path = p
+	}
+
+	if doc, err := b.ReadDoc(); err == nil {
+		b.Desc = doc.Summary()
+	}
+
+	return b
+}
+
+// Find the blank with the given name in the first directory
+// that has it. Returns nil if there is none, and every path
+// that was checked, in order.
+func Find(name string, dirs []string) (*Blank, []*Candidate) {
+	var (
+		found *Blank
+		cands []*Candidate
+	)
+
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+
+		for _, c := range candidates(dir, name) {
+			if c.Found && found == nil {
+				found = newBlank(name, dir, c)
+			}
+
+			cands = append(cands, c)
+		}
+	}
+
+	return found, cands
+}
+
+// Returns the command line of the interpreter of a shell
+// script blank: the one in its "#!" line, e.g. ["/bin/bash"]
+// or ["/usr/bin/env", "bash"], or else DefaultShell, which also
+// reports the error if the script cannot be read.
+func (b *Blank) Interpreter() []string {
+	f, err := os.Open(b.Path)
+
+	if err != nil {
+		return []string{DefaultShell}
+	}
+
+	defer f.Close()
+
+	line, _ := bufio.NewReader(f).ReadString('\n')
+
+	if strings.HasPrefix(line, "#!") {
+		if fields := strings.Fields(line[2:]); len(fields) > 0 {
+			return fields
+		}
+	}
+
+	return []string{DefaultShell}
+}
@@ -0,0 +1,490 @@
+package blk
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestList(t *testing.T) {
+	list, err := List([]string{"test/a", "", "test/none", "test/b"})
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, list, []*Blank{
+		{
+			Name:   "app",
+			Dir:    "test/a",
+			Path:   "test/a/app.mk",
+			Engine: EngineMake,
+		},
+		{
+			Name:   "lib",
+			Dir:    "test/a",
+			Path:   "test/a/lib.mk",
+			Engine: EngineMake,
+			Desc:   "A library blank.",
+		},
+		{
+			Name:   "lib",
+			Dir:    "test/b",
+			Path:   "test/b/lib.mk",
+			Engine: EngineMake,
+			Desc:   "Another library blank.",
+			Hidden: true,
+		},
+	})
+
+	list, err = List([]string{"test/e"})
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, list, []*Blank{
+		{
+			Name:   "app",
+			Dir:    "test/e",
+			Path:   "test/e/app.blank.yaml",
+			Engine: EngineRecipe,
+			Desc:   "Generate an app.",
+		},
+		{
+			Name:   "gen",
+			Dir:    "test/e",
+			Path:   "test/e/gen.sh",
+			Engine: EngineShell,
+			Desc:   "Generate with a script.",
+		},
+		{
+			Name:   "lib",
+			Dir:    "test/e",
+			Path:   "test/e/lib.mk",
+			Engine: EngineMake,
+			Desc:   "A makefile blank.",
+		},
+		{
+			Name:   "lib",
+			Dir:    "test/e",
+			Path:   "test/e/lib.sh",
+			Engine: EngineShell,
+			Desc:   "A script blank.",
+			Hidden: true,
+		},
+		{
+			Name:   "site",
+			Dir:    "test/e",
+			Path:   "test/e/site",
+			Engine: EngineTemplate,
+		},
+		{
+			Name:   "tool",
+			Dir:    "test/e",
+			Path:   "test/e/tool",
+			Engine: EngineExec,
+			Desc:   "A tool blank.",
+		},
+	})
+}
+
+func TestFind(t *testing.T) {
+	b, cands := Find("lib", []string{"test/none", "test/b", "test/a"})
+
+	assert.DeepEqual(t, b, &Blank{
+		Name:   "lib",
+		Dir:    "test/b",
+		Path:   "test/b/lib.mk",
+		Engine: EngineMake,
+		Desc:   "Another library blank.",
+	})
+	assert.DeepEqual(t, cands, []*Candidate{
+		{Path: "test/none/lib.mk", Engine: EngineMake},
+		{Path: "test/none/lib.sh", Engine: EngineShell},
+		{Path: "test/none/lib.blank.yaml", Engine: EngineRecipe},
+		{Path: "test/none/lib", Engine: EngineExec},
+		{Path: "test/b/lib.mk", Engine: EngineMake, Found: true},
+		{Path: "test/b/lib.sh", Engine: EngineShell},
+		{Path: "test/b/lib.blank.yaml", Engine: EngineRecipe},
+		{Path: "test/b/lib", Engine: EngineExec},
+		{Path: "test/a/lib.mk", Engine: EngineMake, Found: true},
+		{Path: "test/a/lib.sh", Engine: EngineShell},
+		{Path: "test/a/lib.blank.yaml", Engine: EngineRecipe},
+		{Path: "test/a/lib", Engine: EngineExec},
+	})
+
+	tests := map[string]string{
+		"gen":   EngineShell,
+		"tool":  EngineExec,
+		"site":  EngineTemplate,
+		"app":   EngineRecipe,
+		"notes": "",
+	}
+
+	for name, engine := range tests {
+		b, _ = Find(name, []string{"test/e"})
+
+		if engine == "" {
+			assert.Assert(t, b == nil, name)
+		} else {
+			assert.Equal(t, b.Engine, engine, name)
+		}
+	}
+
+	b, _ = Find("none", []string{"test/a"})
+	assert.Assert(t, b == nil)
+}
+
+func TestInterpreter(t *testing.T) {
+	bash := filepath.Join(t.TempDir(), "bash.sh")
+	assert.NilError(t, ioutil.WriteFile(bash, []byte("#! /usr/bin/env bash\necho\n"), 0644))
+
+	tests := map[string][]string{
+		"test/e/gen.sh":  {"/bin/sh"},
+		"test/e/lib.sh":  {DefaultShell},
+		"test/e/none.sh": {DefaultShell},
+		bash:             {"/usr/bin/env", "bash"},
+	}
+
+	for p, want := range tests {
+		assert.DeepEqual(t, (&Blank{Path: p}).Interpreter(), want)
+	}
+}
+
+func TestCleanPaths(t *testing.T) {
+	home, _ := os.UserHomeDir()
+	wd, _ := os.Getwd()
+
+	paths := CleanPaths([]string{"", "/a b/c", "~", "~/x", "test/a", "~x"})
+
+	assert.DeepEqual(t, paths, []string{
+		"/a b/c",
+		home,
+		filepath.Join(home, "x"),
+		filepath.Join(wd, "test/a"),
+		filepath.Join(wd, "~x"),
+	})
+}
+
+func TestReadDoc(t *testing.T) {
+	tests := map[string]struct {
+		Path string
+		Doc  *Doc
+	}{
+		"header": {
+			"test/c/svc.mk",
+			&Doc{
+				Desc: "Generate a service.\n\nCreates a main file and a makefile.",
+				Params: []*Param{
+					{Name: "name", Default: "svc", Desc: "The service name."},
+					{Name: "port", Desc: "The port to listen on."},
+				},
+				Examples: []string{"blank svc name=billing"},
+				Files:    []string{"main.go", "Makefile"},
+			},
+		},
+		"manifest": {
+			"test/c/web.mk",
+			&Doc{
+				Desc: "Generate a website.\nWith more details.",
+				Params: []*Param{
+					{Name: "title", Default: "Home", Desc: "The page title."},
+				},
+				Files: []string{"index.html"},
+			},
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			doc, err := (&Blank{Path: tt.Path}).ReadDoc()
+			assert.NilError(t, err)
+			assert.DeepEqual(t, doc, tt.Doc)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	doc := &Doc{
+		Params: []*Param{
+			{Name: "name", Required: true, Regex: "[a-z]+"},
+			{Name: "port", Type: TypeInt, Default: "8080"},
+			{Name: "db", Enum: []string{"pg", "my"}},
+			{Name: "debug", Type: TypeBool},
+			{Name: "env", Required: true, Default: "dev"},
+		},
+	}
+
+	for _, p := range doc.Params {
+		assert.NilError(t, p.validate())
+	}
+
+	tests := map[string]struct {
+		Vals map[string]string
+		Res  map[string]string
+		Err  string
+	}{
+		"defaults": {
+			map[string]string{"name": "a", "other": "x"},
+			map[string]string{"name": "a", "port": "8080", "env": "dev", "other": "x"},
+			"",
+		},
+		"valid": {
+			map[string]string{"name": "a", "port": "1", "db": "my", "debug": "true", "env": "ci"},
+			map[string]string{"name": "a", "port": "1", "db": "my", "debug": "true", "env": "ci"},
+			"",
+		},
+		"required": {
+			map[string]string{},
+			nil,
+			"The name parameter is required",
+		},
+		"invalid": {
+			map[string]string{"name": "A", "port": "x", "db": "x", "debug": "x"},
+			nil,
+			`The name parameter must match "[a-z]+", not "A"
+The port parameter must be an integer, not "x"
+The db parameter must be one of pg, my, not "x"
+The debug parameter must be true or false, not "x"`,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			res, err := doc.Resolve(tt.Vals)
+
+			if tt.Err != "" {
+				assert.Error(t, err, tt.Err)
+			} else {
+				assert.NilError(t, err)
+			}
+
+			assert.DeepEqual(t, res, tt.Res)
+		})
+	}
+}
+
+func TestData(t *testing.T) {
+	doc := &Doc{
+		Params: []*Param{
+			{Name: "name"},
+			{Name: "port", Type: TypeInt},
+			{Name: "debug", Type: TypeBool},
+			{Name: "ci", Type: TypeBool},
+		},
+	}
+
+	data := doc.Data(map[string]string{"port": "80", "ci": "false", "other": "1"})
+
+	assert.DeepEqual(t, data, map[string]interface{}{
+		"name":  "",
+		"port":  80,
+		"debug": false,
+		"ci":    false,
+		"other": "1",
+	})
+}
+
+func TestPlan(t *testing.T) {
+	dirs := []string{"test/d"}
+
+	tests := map[string]struct {
+		Target string
+		Plan   []string
+		Err    string
+	}{
+		"deps":    {"app", []string{"base", "lib", "ci", "app"}, ""},
+		"none":    {"base", []string{"base"}, ""},
+		"cycle":   {"cyc1", nil, "Dependency cycle: cyc1 -> cyc2 -> cyc1"},
+		"missing": {"miss", nil, "No target found: none (required by miss)"},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			b, _ := Find(tt.Target, dirs)
+			plan, err := Plan(b, dirs)
+
+			if tt.Err != "" {
+				assert.Error(t, err, tt.Err)
+				return
+			}
+
+			assert.NilError(t, err)
+
+			names := make([]string, len(plan))
+			for i, s := range plan {
+				names[i] = s.Blank.Name
+			}
+
+			assert.DeepEqual(t, names, tt.Plan)
+		})
+	}
+
+	b, _ := Find("app", dirs)
+	plan, _ := Plan(b, dirs)
+	vals := plan[3].Depends[1].Expand(map[string]string{"name": "x"})
+
+	assert.DeepEqual(t, vals, map[string]string{"project": "x-ci"})
+}
+
+func TestReadRecipe(t *testing.T) {
+	r, err := (&Blank{Path: "test/e/app.blank.yaml"}).ReadRecipe()
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, r, &Recipe{
+		Steps: []*RecipeStep{
+			{Mkdir: "${name}"},
+			{
+				Name: "Copy the sources",
+				Copy: &CopyAction{From: "src", To: "${name}"},
+			},
+			{
+				Update: &UpdateAction{
+					File:     "package.json",
+					Path:     "/scripts",
+					Data:     map[string]interface{}{"start": "node ${name}"},
+					Strategy: "${name}.rules.yaml",
+				},
+			},
+			{Run: "npm install"},
+			{Include: &IncludeAction{Target: "lib"}},
+			{
+				Include: &IncludeAction{
+					Target: "ci",
+					Params: map[string]string{"app": "${name}"},
+				},
+			},
+		},
+	})
+
+	var kinds, strs []string
+
+	for _, s := range r.Steps {
+		s = s.Expand(map[string]string{"name": "web"})
+		kinds = append(kinds, s.Kind())
+		strs = append(strs, s.String())
+	}
+
+	assert.DeepEqual(t, kinds, []string{
+		StepMkdir, StepCopy, StepUpdate, StepRun, StepInclude, StepInclude,
+	})
+	assert.DeepEqual(t, strs, []string{
+		"mkdir web",
+		"Copy the sources",
+		"update package.json",
+		"run npm install",
+		"include lib",
+		"include ci",
+	})
+	step := &RecipeStep{Update: &UpdateAction{
+		File:     "${name}.json",
+		Data:     map[string]interface{}{"a": []interface{}{"${name} ${x} $name", 1}},
+		Strategy: "${name}.yaml",
+	}}
+
+	assert.DeepEqual(t, step.Expand(map[string]string{"name": "web"}).Update, &UpdateAction{
+		File:     "web.json",
+		Data:     map[string]interface{}{"a": []interface{}{"web ${x} $name", 1}},
+		Strategy: "web.yaml",
+	})
+
+	_, err = (&Blank{Path: "test/c/bad.blank.yaml"}).ReadRecipe()
+	assert.Error(t, err, "test/c/bad.blank.yaml: step 1: more than one action: mkdir, run")
+}
+
+func TestRecipeStepDest(t *testing.T) {
+	tests := []struct {
+		step *RecipeStep
+		dest string
+		err  string
+	}{
+		{step: &RecipeStep{Copy: &CopyAction{From: "src"}}, dest: "."},
+		{step: &RecipeStep{Render: &CopyAction{From: "src", To: "a/../b"}}, dest: "a/../b"},
+		{step: &RecipeStep{Mkdir: "a"}, dest: "a"},
+		{step: &RecipeStep{Run: "true"}, dest: ""},
+		{
+			step: &RecipeStep{Copy: &CopyAction{From: "src", To: "../a"}},
+			err:  "copy: path is not in the working directory: ../a",
+		},
+		{
+			step: &RecipeStep{Update: &UpdateAction{File: "/etc/hosts"}},
+			err:  "update: path is not in the working directory: /etc/hosts",
+		},
+		{
+			step: &RecipeStep{Delete: "a/../.."},
+			err:  "delete: path is not in the working directory: a/../..",
+		},
+		{
+			step: &RecipeStep{Delete: "."},
+			err:  "delete: path is not in the working directory: .",
+		},
+		{
+			step: &RecipeStep{Mkdir: "../a"},
+			err:  "mkdir: path is not in the working directory: ../a",
+		},
+	}
+
+	for _, test := range tests {
+		dest, err := test.step.Dest()
+
+		if test.err != "" {
+			assert.Error(t, err, test.err)
+			continue
+		}
+
+		assert.NilError(t, err)
+		assert.Equal(t, dest, test.dest)
+	}
+}
+
+func TestHash(t *testing.T) {
+	hash := func(target string, paths ...string) string {
+		b, _ := Find(target, paths)
+		assert.Assert(t, b != nil, target)
+
+		h, err := b.Hash()
+		assert.NilError(t, err)
+
+		return h
+	}
+
+	assert.Equal(t, hash("lib", "test/a"), hash("lib", "test/a"))
+	assert.Assert(t, hash("lib", "test/a") != hash("lib", "test/b"))
+
+	dir := t.TempDir()
+	site := filepath.Join(dir, "site")
+
+	assert.NilError(t, os.Mkdir(site, 0755))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(site, "a"), []byte("a"), 0644))
+
+	prev := hash("site", dir)
+
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "site.yaml"), []byte("{}"), 0644))
+	assert.Assert(t, hash("site", dir) != prev)
+
+	prev = hash("site", dir)
+
+	assert.NilError(t, os.Rename(filepath.Join(site, "a"), filepath.Join(site, "b")))
+	assert.Assert(t, hash("site", dir) != prev)
+}
+
+func TestReadTestSpec(t *testing.T) {
+	b, _ := Find("gen", []string{"test/e"})
+	spec, err := b.ReadTestSpec()
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, spec, &TestSpec{Cases: []*TestCase{
+		{Name: "default"},
+		{Name: "custom", Params: map[string]string{"name": "other"}},
+	}})
+	assert.Equal(t, b.GoldenPath(spec.Cases[1]), "test/e/gen.golden/custom")
+
+	b, _ = Find("lib", []string{"test/e"})
+	spec, err = b.ReadTestSpec()
+
+	assert.NilError(t, err)
+	assert.Assert(t, spec == nil)
+
+	b, _ = Find("svc", []string{"test/c"})
+	_, err = b.ReadTestSpec()
+
+	assert.ErrorContains(t, err, `case "a" is not unique`)
+}
@@ -0,0 +1,116 @@
+package blk
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A dependency of a blank on another blank, which is run
+// before it with the given parameter values. Values may refer
+// to the dependent blank's parameters as "${name}".
+type Dep struct {
+	Name   string            `yaml:"name"`
+	Params map[string]string `yaml:"params"`
+}
+
+// A dependency is either a name, or an object.
+func (d *Dep) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		return n.Decode(&d.Name)
+	}
+
+	type dep Dep
+
+	return n.Decode((*dep)(d))
+}
+
+// Returns the dependency's parameter values, given the values
+// of the dependent blank's parameters.
+func (d *Dep) Expand(vals map[string]string) map[string]string {
+	res := make(map[string]string, len(d.Params))
+
+	for k, v := range d.Params {
+		res[k] = os.Expand(v, func(n string) string {
+			return vals[n]
+		})
+	}
+
+	return res
+}
+
+// A blank in a plan, with its dependencies.
+type Step struct {
+	Blank   *Blank
+	Depends []*Dep
+}
+
+// Returns the blanks that must be run to run blank b: all its
+// direct and indirect dependencies, found in the given dirs,
+// in an order in which every blank follows its dependencies,
+// followed by b itself. Every blank appears once.
+func Plan(b *Blank, dirs []string) ([]*Step, error) {
+	p := &planner{
+		dirs:  dirs,
+		steps: make(map[string]*Step),
+	}
+
+	if err := p.visit(b, nil); err != nil {
+		return nil, err
+	}
+
+	return p.plan, nil
+}
+
+type planner struct {
+	dirs  []string
+	steps map[string]*Step
+	plan  []*Step
+}
+
+// Add blank b to the plan after its dependencies. The chain
+// is the list of blanks that depend on b, to detect cycles.
+func (p *planner) visit(b *Blank, chain []string) error {
+	chain = append(chain, b.Name)
+
+	for _, c := range chain[:len(chain)-1] {
+		if c == b.Name {
+			return fmt.Errorf("Dependency cycle: %s", strings.Join(chain, " -> "))
+		}
+	}
+
+	if _, ok := p.steps[b.Name]; ok {
+		return nil
+	}
+
+	doc, err := b.ReadManifest()
+
+	if err != nil {
+		return err
+	}
+
+	s := &Step{Blank: b}
+
+	if doc != nil {
+		s.Depends = doc.Depends
+	}
+
+	for _, d := range s.Depends {
+		dep, _ := Find(d.Name, p.dirs)
+
+		if dep == nil {
+			return fmt.Errorf("No target found: %s (required by %s)", d.Name, b.Name)
+		}
+
+		if err := p.visit(dep, chain); err != nil {
+			return err
+		}
+	}
+
+	p.steps[b.Name] = s
+	p.plan = append(p.plan, s)
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package blk
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/makeblank/blank/out"
+	"gopkg.in/yaml.v3"
+
+	. "github.com/makeblank/blank/arg"
+)
+
+// The file extension of blank manifests, which are found next
+// to a blank's file.
+const ManifestExt = ".yaml"
+
+// Documentation of a blank, read from its manifest or from
+// the comment block at the top of its file.
+type Doc struct {
+	Desc     string   `yaml:"description"`
+	Params   []*Param `yaml:"params"`
+	Examples []string `yaml:"examples"`
+	Files    []string `yaml:"files"`
+	Depends  []*Dep   `yaml:"depends"`
+
+	// Patterns of files in a template directory that are
+	// copied verbatim (see tpl.IsVerbatim).
+	Verbatim []string `yaml:"verbatim"`
+
+	// The policy for existing files that would change (see
+	// the out package).
+	Conflict string `yaml:"conflict"`
+}
+
+// Returns the first line of the description.
+func (d *Doc) Summary() string {
+	return strings.SplitN(d.Desc, "\n", 2)[0]
+}
+
+// Returns the path to the blank's manifest, which may not
+// exist. A recipe is its own manifest.
+func (b *Blank) ManifestPath() string {
+	if b.Engine == EngineRecipe {
+		return b.Path
+	}
+
+	return strings.TrimSuffix(b.Path, filepath.Ext(b.Path)) + ManifestExt
+}
+
+// Read the blank's manifest. Returns nil if it has none.
+func (b *Blank) ReadManifest() (*Doc, error) {
+	p := b.ManifestPath()
+	content, err := ioutil.ReadFile(p)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	doc := &Doc{}
+
+	if err = yaml.Unmarshal(content, doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+
+	for _, prm := range doc.Params {
+		if err = prm.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+	}
+
+	if doc.Conflict != "" && !out.IsPolicy(doc.Conflict) {
+		return nil, fmt.Errorf("%s: unknown conflict policy: %q", p, doc.Conflict)
+	}
+
+	return doc, nil
+}
+
+// Read the blank's documentation from its manifest if it has
+// one, or else from the comment block at the top of its file.
+// Template directories are only documented by manifests.
+func (b *Blank) ReadDoc() (*Doc, error) {
+	if doc, err := b.ReadManifest(); doc != nil || err != nil {
+		return doc, err
+	}
+
+	doc := &Doc{}
+
+	if b.Engine == EngineTemplate {
+		return doc, nil
+	}
+
+	return doc, doc.readHeader(b.Path)
+}
+
+// Section names in a header comment block.
+const (
+	paramsSection   = "Variables:"
+	examplesSection = "Examples:"
+	filesSection    = "Files:"
+)
+
+// Read the "##" comment lines at the top of a file, e.g.
+//
+//	## One-line description.
+//	##
+//	## Longer description.
+//	##
+//	## Variables:
+//	##   name=default  Description of name.
+//	##
+//	## Examples:
+//	##   blank target name=value
+//	##
+//	## Files:
+//	##   created/file
+func (d *Doc) readHeader(p string) error {
+	var (
+		desc    []string
+		section string
+		started bool
+	)
+
+	f, err := os.Open(p)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+
+		if !strings.HasPrefix(line, "##") {
+			if started || line != "" && line[0] != '#' {
+				break
+			}
+			continue
+		}
+
+		started = true
+		text := strings.TrimSpace(line[2:])
+
+		switch {
+		case IsWord(text, paramsSection, examplesSection, filesSection):
+			section = text
+		case section == "":
+			desc = append(desc, text)
+		case text == "":
+		case section == paramsSection:
+			d.Params = append(d.Params, parseParam(text))
+		case section == examplesSection:
+			d.Examples = append(d.Examples, text)
+		case section == filesSection:
+			d.Files = append(d.Files, text)
+		}
+	}
+
+	d.Desc = strings.TrimSpace(strings.Join(desc, "\n"))
+
+	return s.Err()
+}
+
+// Parse a "name[=default]  description" line.
+func parseParam(s string) *Param {
+	p := &Param{}
+	f := strings.SplitN(s, " ", 2)
+
+	if len(f) == 2 {
+		p.Desc = strings.TrimSpace(f[1])
+	}
+
+	nv := strings.SplitN(f[0], "=", 2)
+	p.Name = nv[0]
+
+	if len(nv) == 2 {
+		p.Default = nv[1]
+	}
+
+	return p
+}
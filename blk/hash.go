@@ -0,0 +1,59 @@
+package blk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/makeblank/blank/snap"
+)
+
+// Returns the hash of the blank's content: its file, or the
+// files in its template directory, and its manifest if it has
+// one. It changes whenever the blank changes.
+func (b *Blank) Hash() (string, error) {
+	h := sha256.New()
+
+	if b.Engine == EngineTemplate {
+		s, err := snap.Take(b.Path)
+
+		if err != nil {
+			return "", err
+		}
+
+		paths := make([]string, 0, len(s))
+
+		for p := range s {
+			paths = append(paths, p)
+		}
+
+		sort.Strings(paths)
+
+		for _, p := range paths {
+			fmt.Fprintf(h, "%s %s\n", s[p].Hash, p)
+		}
+	} else if err := hashContent(h, b.Path); err != nil {
+		return "", err
+	}
+
+	if p := b.ManifestPath(); p != b.Path {
+		if err := hashContent(h, p); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func hashContent(w interface{ Write([]byte) (int, error) }, p string) error {
+	content, err := ioutil.ReadFile(p)
+
+	if err == nil {
+		w.Write(content) //nolint:errcheck
+	}
+
+	return err
+}
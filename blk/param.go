@@ -0,0 +1,195 @@
+package blk
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	. "github.com/makeblank/blank/arg"
+)
+
+// Parameter types.
+const (
+	TypeString = "string"
+	TypeInt    = "int"
+	TypeBool   = "bool"
+)
+
+// A blank's parameter, i.e. a make variable.
+type Param struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type"`
+	Default  string   `yaml:"default"`
+	Enum     []string `yaml:"enum"`
+	Regex    string   `yaml:"regex"`
+	Required bool     `yaml:"required"`
+	Desc     string   `yaml:"description"`
+}
+
+// An invalid parameter value.
+type ParamError struct {
+	Name string
+	Desc string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("The %s parameter %s", e.Name, e.Desc)
+}
+
+// All invalid parameter values of a blank.
+type ParamErrors []*ParamError
+
+func (e ParamErrors) Error() string {
+	s := make([]string, len(e))
+
+	for i, err := range e {
+		s[i] = err.Error()
+	}
+
+	return strings.Join(s, "\n")
+}
+
+// Check the parameter's declaration.
+func (p *Param) validate() (err error) {
+	if p.Name == "" {
+		return fmt.Errorf("parameter name is required")
+	}
+
+	if !IsWord(p.Type, "", TypeString, TypeInt, TypeBool) {
+		return fmt.Errorf("unknown type of parameter %s: %q", p.Name, p.Type)
+	}
+
+	if _, err = p.compile(); err != nil {
+		return fmt.Errorf("invalid regex of parameter %s: %w", p.Name, err)
+	}
+
+	if p.Default != "" {
+		if err := p.Check(p.Default); err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Compile the regex so that it matches entire values.
+// Returns nil if there is none.
+func (p *Param) compile() (*regexp.Regexp, error) {
+	if p.Regex == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + p.Regex + ")$")
+}
+
+// Check value v against the parameter's declaration.
+func (p *Param) Check(v string) *ParamError {
+	var desc string
+
+	switch p.Type {
+	case TypeInt:
+		if _, err := strconv.Atoi(v); err != nil {
+			desc = fmt.Sprintf("must be an integer, not %q", v)
+		}
+	case TypeBool:
+		if _, err := strconv.ParseBool(v); err != nil {
+			desc = fmt.Sprintf("must be true or false, not %q", v)
+		}
+	}
+
+	if desc == "" && len(p.Enum) > 0 && !IsWord(v, p.Enum...) {
+		desc = fmt.Sprintf(
+			"must be one of %s, not %q",
+			strings.Join(p.Enum, ", "),
+			v,
+		)
+	}
+
+	if re, _ := p.compile(); desc == "" && re != nil && !re.MatchString(v) {
+		desc = fmt.Sprintf("must match %q, not %q", p.Regex, v)
+	}
+
+	if desc != "" {
+		return &ParamError{p.Name, desc}
+	}
+
+	return nil
+}
+
+// Returns the required parameters that have no value and no
+// default.
+func (d *Doc) Missing(vals map[string]string) (m []*Param) {
+	for _, p := range d.Params {
+		if _, ok := vals[p.Name]; p.Required && !ok && p.Default == "" {
+			m = append(m, p)
+		}
+	}
+	return
+}
+
+// Check the given parameter values against the declarations
+// in the manifest, and return them with defaults filled in
+// for missing parameters.
+func (d *Doc) Resolve(vals map[string]string) (map[string]string, error) {
+	var (
+		errs ParamErrors
+		res  = make(map[string]string, len(vals))
+	)
+
+	for k, v := range vals {
+		res[k] = v
+	}
+
+	for _, p := range d.Params {
+		v, ok := vals[p.Name]
+
+		if !ok {
+			if p.Default != "" {
+				res[p.Name] = p.Default
+			} else if p.Required {
+				errs = append(errs, &ParamError{p.Name, "is required"})
+			}
+			continue
+		}
+
+		if err := p.Check(v); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	return res, nil
+}
+
+// Returns the given parameter values as template data. The
+// values of int and bool parameters declared in the manifest
+// are numbers and booleans, so that e.g. {{ if .debug }} is
+// false for debug=false, and declared parameters without a
+// value are zero values. Other values are strings.
+func (d *Doc) Data(vals map[string]string) map[string]interface{} {
+	data := make(map[string]interface{}, len(vals))
+
+	for k, v := range vals {
+		data[k] = v
+	}
+
+	for _, p := range d.Params {
+		v := vals[p.Name]
+
+		switch p.Type {
+		case TypeInt:
+			n, _ := strconv.Atoi(v)
+			data[p.Name] = n
+		case TypeBool:
+			b, _ := strconv.ParseBool(v)
+			data[p.Name] = b
+		default:
+			data[p.Name] = v
+		}
+	}
+
+	return data
+}
@@ -0,0 +1,327 @@
+package blk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kinds of recipe steps.
+const (
+	StepCopy    = "copy"    // copy a file or directory
+	StepRender  = "render"  // render a template file or directory
+	StepUpdate  = "update"  // update a config file
+	StepDelete  = "delete"  // delete a file or directory
+	StepMkdir   = "mkdir"   // create a directory
+	StepRun     = "run"     // run a shell command
+	StepInclude = "include" // run another blank
+)
+
+var stepKinds = []string{
+	StepCopy,
+	StepRender,
+	StepUpdate,
+	StepDelete,
+	StepMkdir,
+	StepRun,
+	StepInclude,
+}
+
+// A recipe blank, "[target].blank.yaml", which is a manifest
+// that also lists the steps that the blank runs, in order.
+type Recipe struct {
+	Steps []*RecipeStep `yaml:"steps"`
+}
+
+// A step of a recipe, which has exactly one action. Paths to
+// copy or render from are relative to the recipe's directory,
+// and other paths to the working directory. Values other than
+// commands may refer to variables as "${name}" (see Expand).
+type RecipeStep struct {
+	Name string `yaml:"name"` // An optional description.
+
+	Copy    *CopyAction    `yaml:"copy"`
+	Render  *CopyAction    `yaml:"render"`
+	Update  *UpdateAction  `yaml:"update"`
+	Delete  string         `yaml:"delete"`
+	Mkdir   string         `yaml:"mkdir"`
+	Run     string         `yaml:"run"`
+	Include *IncludeAction `yaml:"include"`
+}
+
+// Copies or renders a file or directory.
+type CopyAction struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+}
+
+// Merges data into a config file, like the update command.
+type UpdateAction struct {
+	File string      `yaml:"file"`
+	Path string      `yaml:"path"` // Where to merge data, "/" if empty.
+	Mode string      `yaml:"mode"` // A cfg merge mode, "merge" if empty.
+	Key  string      `yaml:"key"`
+	Data interface{} `yaml:"data"`
+
+	// A strategy file, relative to the recipe's directory,
+	// whose rules are used instead of Mode at their paths.
+	Strategy string `yaml:"strategy"`
+}
+
+// Runs another blank with the given parameter values.
+type IncludeAction struct {
+	Target string            `yaml:"target"`
+	Params map[string]string `yaml:"params"`
+}
+
+// An include action is either a target, or an object.
+func (a *IncludeAction) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		return n.Decode(&a.Target)
+	}
+
+	type include IncludeAction
+
+	return n.Decode((*include)(a))
+}
+
+// Read the recipe of a recipe blank.
+func (b *Blank) ReadRecipe() (*Recipe, error) {
+	content, err := ioutil.ReadFile(b.Path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Recipe{}
+
+	if err = yaml.Unmarshal(content, r); err != nil {
+		return nil, fmt.Errorf("%s: %w", b.Path, err)
+	}
+
+	for i, s := range r.Steps {
+		if err = s.validate(); err != nil {
+			return nil, fmt.Errorf("%s: step %d: %w", b.Path, i+1, err)
+		}
+	}
+
+	return r, nil
+}
+
+// Returns the kind of the step's action.
+func (s *RecipeStep) Kind() string {
+	return s.kinds()[0]
+}
+
+// Returns the kinds of all actions the step has, or a list
+// with an empty kind if it has none.
+func (s *RecipeStep) kinds() (k []string) {
+	has := []bool{
+		s.Copy != nil,
+		s.Render != nil,
+		s.Update != nil,
+		s.Delete != "",
+		s.Mkdir != "",
+		s.Run != "",
+		s.Include != nil,
+	}
+
+	for i, kind := range stepKinds {
+		if has[i] {
+			k = append(k, kind)
+		}
+	}
+
+	if len(k) == 0 {
+		k = []string{""}
+	}
+
+	return
+}
+
+func (s *RecipeStep) validate() error {
+	var missing string
+
+	if k := s.kinds(); len(k) > 1 {
+		return fmt.Errorf("more than one action: %s", strings.Join(k, ", "))
+	}
+
+	switch s.Kind() {
+	case "":
+		return fmt.Errorf("no action")
+	case StepCopy:
+		if s.Copy.From == "" {
+			missing = "from"
+		}
+	case StepRender:
+		if s.Render.From == "" {
+			missing = "from"
+		}
+	case StepUpdate:
+		if s.Update.File == "" {
+			missing = "file"
+		} else if s.Update.Data == nil {
+			missing = "data"
+		}
+	case StepInclude:
+		if s.Include.Target == "" {
+			missing = "target"
+		}
+	}
+
+	if missing != "" {
+		return fmt.Errorf("%s: %q is required", s.Kind(), missing)
+	}
+
+	_, err := s.Dest()
+
+	return err
+}
+
+// Returns the path in the working directory that the step
+// writes to: the destination of a copy or render step, "." if
+// it has none, or the path of an update, delete or mkdir step.
+// Returns an empty path for other steps. It is an error if the
+// path is not in the working directory, or is the working
+// directory itself and the step would replace it.
+func (s *RecipeStep) Dest() (string, error) {
+	var p string
+
+	switch s.Kind() {
+	case StepCopy:
+		p = s.Copy.To
+	case StepRender:
+		p = s.Render.To
+	case StepUpdate:
+		p = s.Update.File
+	case StepDelete:
+		p = s.Delete
+	case StepMkdir:
+		p = s.Mkdir
+	default:
+		return "", nil
+	}
+
+	if p == "" {
+		p = "."
+	}
+
+	var (
+		sep  = string(filepath.Separator)
+		rel  = filepath.Clean(filepath.FromSlash(p))
+		into = s.Kind() == StepCopy || s.Kind() == StepRender
+	)
+
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+sep) || rel == "." && !into {
+		return p, fmt.Errorf("%s: path is not in the working directory: %s", s.Kind(), p)
+	}
+
+	return p, nil
+}
+
+var varRef = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// Returns a copy of the step in which "${name}" references to
+// the given variables are replaced by their values. Other
+// references are left as they are, and so are commands,
+// which get variables from their environment.
+func (s *RecipeStep) Expand(vals map[string]string) *RecipeStep {
+	expand := func(v string) string {
+		return varRef.ReplaceAllStringFunc(v, func(ref string) string {
+			if val, ok := vals[ref[2:len(ref)-1]]; ok {
+				return val
+			}
+			return ref
+		})
+	}
+
+	c := *s
+	c.Name = expand(s.Name)
+	c.Delete = expand(s.Delete)
+	c.Mkdir = expand(s.Mkdir)
+
+	if s.Copy != nil {
+		c.Copy = &CopyAction{expand(s.Copy.From), expand(s.Copy.To)}
+	}
+
+	if s.Render != nil {
+		c.Render = &CopyAction{expand(s.Render.From), expand(s.Render.To)}
+	}
+
+	if s.Update != nil {
+		u := *s.Update
+		u.File = expand(u.File)
+		u.Path = expand(u.Path)
+		u.Strategy = expand(u.Strategy)
+		u.Data = expandData(u.Data, expand)
+		c.Update = &u
+	}
+
+	if s.Include != nil {
+		c.Include = &IncludeAction{
+			Target: expand(s.Include.Target),
+			Params: make(map[string]string, len(s.Include.Params)),
+		}
+
+		for k, v := range s.Include.Params {
+			c.Include.Params[k] = expand(v)
+		}
+	}
+
+	return &c
+}
+
+// Returns a copy of config data in which all strings are
+// expanded.
+func expandData(v interface{}, expand func(string) string) interface{} {
+	switch t := v.(type) {
+	case string:
+		return expand(t)
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = expandData(e, expand)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, e := range t {
+			a[i] = expandData(e, expand)
+		}
+		return a
+	}
+
+	return v
+}
+
+// Returns a one-line description of the step.
+func (s *RecipeStep) String() string {
+	if s.Name != "" {
+		return s.Name
+	}
+
+	switch s.Kind() {
+	case StepCopy:
+		to, _ := s.Dest()
+		return fmt.Sprintf("copy %s to %s", s.Copy.From, to)
+	case StepRender:
+		to, _ := s.Dest()
+		return fmt.Sprintf("render %s to %s", s.Render.From, to)
+	case StepUpdate:
+		return fmt.Sprintf("update %s", s.Update.File)
+	case StepDelete:
+		return fmt.Sprintf("delete %s", s.Delete)
+	case StepMkdir:
+		return fmt.Sprintf("mkdir %s", s.Mkdir)
+	case StepRun:
+		return fmt.Sprintf("run %s", s.Run)
+	case StepInclude:
+		return fmt.Sprintf("include %s", s.Include.Target)
+	}
+
+	return ""
+}
@@ -0,0 +1,83 @@
+package blk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The extension of a blank's test spec, "[target].test.yaml".
+const TestSpecExt = ".test.yaml"
+
+// The extension of the directory that holds a blank's golden
+// trees, "[target].golden", one per test case.
+const GoldenExt = ".golden"
+
+// The name of an empty file that is written into a golden
+// tree that would be empty, so that git keeps its directory.
+// It is not part of the tree.
+const GoldenKeepFile = ".blank-keep"
+
+// The name of the case of a blank without a test spec.
+const DefaultTestCase = "default"
+
+// A blank's test spec: the parameter sets that it is tested
+// with.
+type TestSpec struct {
+	Cases []*TestCase `yaml:"cases"`
+}
+
+// A test case of a blank.
+type TestCase struct {
+	Name   string            `yaml:"name"`
+	Params map[string]string `yaml:"params,omitempty"`
+}
+
+// Returns the path of the blank's test spec.
+func (b *Blank) TestSpecPath() string {
+	return filepath.Join(filepath.Dir(b.Path), b.Name+TestSpecExt)
+}
+
+// Returns the path of the golden tree of the blank's test
+// case c.
+func (b *Blank) GoldenPath(c *TestCase) string {
+	return filepath.Join(filepath.Dir(b.Path), b.Name+GoldenExt, c.Name)
+}
+
+// Read the blank's test spec. Returns nil if it has none.
+func (b *Blank) ReadTestSpec() (*TestSpec, error) {
+	p := b.TestSpecPath()
+	content, err := ioutil.ReadFile(p)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	spec := &TestSpec{}
+
+	if err = yaml.Unmarshal(content, spec); err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+
+	names := make(map[string]bool, len(spec.Cases))
+
+	for i, c := range spec.Cases {
+		switch {
+		case c.Name == "":
+			return nil, fmt.Errorf("%s: case %d has no name", p, i+1)
+		case names[c.Name]:
+			return nil, fmt.Errorf("%s: case %q is not unique", p, c.Name)
+		case c.Name != filepath.Base(c.Name) || c.Name == "." || c.Name == "..":
+			return nil, fmt.Errorf("%s: case %q is not a file name", p, c.Name)
+		}
+
+		names[c.Name] = true
+	}
+
+	return spec, nil
+}
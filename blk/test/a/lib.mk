@@ -0,0 +1,7 @@
+#!/usr/bin/make -f
+
+## A library blank.
+## More details.
+
+all:
+	@echo lib
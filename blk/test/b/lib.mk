@@ -0,0 +1,3 @@
+## Another library blank.
+all:
+	@echo lib b
@@ -0,0 +1,21 @@
+# vim: ft=make
+
+## Generate a service.
+##
+## Creates a main file and a makefile.
+##
+## Variables:
+##   name=svc   The service name.
+##   port       The port to listen on.
+##
+## Examples:
+##   blank svc name=billing
+##
+## Files:
+##   main.go
+##   Makefile
+#
+# Not documentation.
+
+all:
+	@echo $(name)
@@ -0,0 +1,3 @@
+## Not used, because of the manifest.
+all:
+	@echo web
@@ -0,0 +1,2 @@
+all:
+	@echo cyc1
@@ -0,0 +1,5 @@
+#!/bin/sh
+
+## Generate with a script.
+
+echo gen
@@ -0,0 +1,4 @@
+## A makefile blank.
+
+all:
+	@echo lib
@@ -0,0 +1,3 @@
+## A script blank.
+
+echo lib
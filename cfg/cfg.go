@@ -70,7 +70,15 @@ func ReadFile(p string, t ...string) (*File, error) {
 
 func (f *File) MergeSource(srcs ...*Source) error {
 	for _, s := range srcs {
-		if err := mergo.Merge(&f.Data, s.File.Data, s.Options...); err != nil {
+		var err error
+
+		if s.Rule != nil || s.Strategy != nil || s.Path != "" {
+			f.Data, err = s.merge(f.Data)
+		} else {
+			err = mergo.Merge(&f.Data, s.File.Data, s.Options...)
+		}
+
+		if err != nil {
 			return err
 		}
 	}
@@ -87,6 +95,51 @@ func (f *File) MergeMap(
 type Source struct {
 	File    *File
 	Options []func(*mergo.Config)
+
+	// If either is not nil, the source is merged according to
+	// the strategy, using the rule for values that have none,
+	// and Options are ignored.
+	Rule     *Rule
+	Strategy Strategy
+
+	// If not empty, the source is merged into every value that
+	// matches this path pattern (see Match), instead of the
+	// entire data, and Options are ignored.
+	Path string
+}
+
+func (s *Source) merge(dst map[string]interface{}) (map[string]interface{}, error) {
+	var (
+		data interface{} = dst
+		r                = s.Rule
+	)
+
+	if r == nil {
+		r = &Rule{Mode: ModeMerge}
+	}
+
+	if s.Path == "" {
+		data = s.Strategy.merge("", data, s.File.Data, r)
+		return data.(map[string]interface{}), nil
+	}
+
+	ptrs, err := Match(data, s.Path)
+
+	if err != nil {
+		return dst, err
+	} else if len(ptrs) == 0 {
+		return dst, fmt.Errorf("%w: %s", ErrNoMatch, s.Path)
+	}
+
+	for _, p := range ptrs {
+		v := s.Strategy.merge(p, Get(data, p), Copy(s.File.Data), r)
+
+		if data, err = Set(data, p, v); err != nil {
+			return dst, err
+		}
+	}
+
+	return data.(map[string]interface{}), nil
 }
 
 func ReadSourceBytes(
@@ -97,7 +150,7 @@ func ReadSourceBytes(
 	if file, err := ReadBytes(d, p); err != nil {
 		return nil, err
 	} else {
-		return &Source{file, o}, nil
+		return &Source{File: file, Options: o}, nil
 	}
 }
 
@@ -105,7 +158,7 @@ func ReadSourceFile(p string, o ...func(*mergo.Config)) (*Source, error) {
 	if file, err := ReadFile(p); err != nil {
 		return nil, err
 	} else {
-		return &Source{file, o}, nil
+		return &Source{File: file, Options: o}, nil
 	}
 }
 
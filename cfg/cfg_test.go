@@ -2,6 +2,7 @@ package cfg
 
 import (
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"testing"
 
@@ -97,6 +98,188 @@ func testMerge(t *testing.T, src, res string, opts ...func(*mergo.Config)) {
 	assert.DeepEqual(t, file.Data, resdata)
 }
 
+func TestFileMergeRule(t *testing.T) {
+	tests := map[string]struct {
+		Src  string
+		Res  string
+		Rule *Rule
+	}{
+		"1": {"test/r1_src.json", "test/r1_res.json", &Rule{ModeMerge, "id"}},
+		"2": {"test/r2_src.json", "test/r2_res.json", &Rule{ModeSet, "id"}},
+		"3": {"test/r3_src.json", "test/r3_res.json", &Rule{ModeAppend, ""}},
+		"4": {"test/r4_src.json", "test/r4_res.json", &Rule{ModeAppendUnique, ""}},
+		"5": {"test/r5_src.json", "test/r5_res.json", &Rule{ModePrepend, ""}},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			testMergeRule(t, tt.Src, tt.Res, tt.Rule)
+		})
+	}
+}
+
+func testMergeRule(t *testing.T, src, res string, rule *Rule) {
+	var (
+		err     error
+		file    *File
+		source  *Source
+		resfile *File
+	)
+
+	if file, err = ReadFile("test/rule_target.json"); err != nil {
+		t.Fatal("cannot open test/rule_target.json")
+	}
+
+	if resfile, err = ReadFile(res); err != nil {
+		t.Fatalf("cannot read res: %s", res)
+	}
+
+	if source, err = ReadSourceFile(src); err != nil {
+		t.Fatalf("cannot read src: %s", src)
+	}
+
+	source.Rule = rule
+	err = file.MergeSource(source)
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, file.Data, resfile.Data)
+}
+
+func TestFileMergeStrategy(t *testing.T) {
+	var (
+		err      error
+		file     *File
+		source   *Source
+		resfile  *File
+		strategy Strategy
+	)
+
+	if file, err = ReadFile("test/rule_target.json"); err != nil {
+		t.Fatal("cannot open test/rule_target.json")
+	}
+
+	if resfile, err = ReadFile("test/s1_res.json"); err != nil {
+		t.Fatal("cannot read test/s1_res.json")
+	}
+
+	if source, err = ReadSourceFile("test/s1_src.json"); err != nil {
+		t.Fatal("cannot read test/s1_src.json")
+	}
+
+	strategy, err = ReadStrategyFile("test/s1_strategy.yaml")
+	assert.NilError(t, err)
+
+	source.Strategy = strategy
+	err = file.MergeSource(source)
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, file.Data, resfile.Data)
+}
+
+func TestNewStrategy(t *testing.T) {
+	s, err := NewStrategy(map[string]interface{}{
+		"a/b/": "union",
+		"/":    map[string]interface{}{"key": "id"},
+	})
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, s, Strategy{
+		"/a/b": &Rule{Mode: ModeUnion},
+		"":     &Rule{Mode: ModeMerge, Key: "id"},
+	})
+
+	_, err = NewStrategy(map[string]interface{}{"/a": "unknown"})
+	assert.ErrorContains(t, err, "unknown merge mode")
+}
+
+func TestMatch(t *testing.T) {
+	var data interface{} = map[string]interface{}{
+		"a": []interface{}{
+			map[string]interface{}{"n": "x", "v": 1.0},
+			map[string]interface{}{"n": "y/z", "v": 2.0},
+		},
+		"o": map[string]interface{}{
+			"b": map[string]interface{}{"v": 3.0},
+			"c": map[string]interface{}{},
+		},
+	}
+
+	tests := map[string]struct {
+		Pattern string
+		Res     []string
+	}{
+		"literal":  {"/o/b/v", []string{"/o/b/v"}},
+		"missing":  {"/o/b/w", []string{"/o/b/w"}},
+		"none":     {"/x/y", nil},
+		"wildcard": {"/o/*/v", []string{"/o/b/v", "/o/c/v"}},
+		"array":    {"/a/*", []string{"/a/0", "/a/1"}},
+		"selector": {"/a/[n=y/z]/v", []string{"/a/1/v"}},
+		"number":   {"/a/[v=1]", []string{"/a/0"}},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			res, err := Match(data, tt.Pattern)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, res, tt.Res)
+		})
+	}
+}
+
+func TestFileMergePath(t *testing.T) {
+	var (
+		err     error
+		file    *File
+		resfile *File
+	)
+
+	if file, err = ReadFile("test/rule_target.json"); err != nil {
+		t.Fatal("cannot open test/rule_target.json")
+	}
+
+	if resfile, err = ReadFile("test/p1_res.json"); err != nil {
+		t.Fatal("cannot read test/p1_res.json")
+	}
+
+	err = file.MergeSource(&Source{
+		File: &File{Data: map[string]interface{}{"w": true}},
+		Path: "/o/[id=2]",
+	})
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, file.Data, resfile.Data)
+
+	err = file.MergeSource(&Source{
+		File: &File{Data: map[string]interface{}{"w": true}},
+		Path: "/o/[id=3]",
+	})
+
+	assert.Assert(t, errors.Is(err, ErrNoMatch))
+}
+
+func TestEqual(t *testing.T) {
+	tests := map[string]struct {
+		A, B  interface{}
+		Equal bool
+	}{
+		"number": {1, 1.0, true},
+		"string": {"1", 1, false},
+		"array":  {[]interface{}{1, "a"}, []interface{}{1.0, "a"}, true},
+		"order":  {[]interface{}{1, "a"}, []interface{}{"a", 1}, false},
+		"object": {
+			map[string]interface{}{"a": 1},
+			map[string]interface{}{"a": 1.0},
+			true,
+		},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, Equal(tt.A, tt.B), tt.Equal)
+		})
+	}
+}
+
 func TestPointerToMap(t *testing.T) {
 	tests := map[string]struct {
 		Pointer string
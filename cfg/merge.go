@@ -0,0 +1,309 @@
+package cfg
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Merge modes used by Rule.
+const (
+	ModeSet    = "set"    // set new values only
+	ModeMerge  = "merge"  // merge values, replacing existing ones
+	ModeAppend = "append" // like ModeMerge, but concatenate arrays
+
+	// Like ModeAppend, but only append values that are not
+	// already in the array.
+	ModeAppendUnique = "append-unique"
+
+	// Like ModeAppendUnique, but insert values at the start
+	// of the array.
+	ModePrepend = "prepend"
+
+	// Like ModeAppendUnique, but also remove repeated values
+	// from the resulting array.
+	ModeUnion = "union"
+
+	// Replace values as a whole, without merging them.
+	ModeOverride = "override"
+)
+
+var modes = []string{
+	ModeSet,
+	ModeMerge,
+	ModeAppend,
+	ModeAppendUnique,
+	ModePrepend,
+	ModeUnion,
+	ModeOverride,
+}
+
+// Describes how source data is merged into target data. It
+// is an alternative to mergo options, for merges that mergo
+// cannot express.
+type Rule struct {
+	Mode string // One of the Mode* constants.
+
+	// If not empty, elements of object arrays are matched by
+	// this field. Matched pairs are merged recursively and
+	// the other elements are appended.
+	Key string
+}
+
+// Maps JSON pointers in target data (e.g. "/path/to/member",
+// or "" for the whole data) to the rules used to merge the
+// values they point to. A rule also applies to all members
+// of the value, unless they have a rule of their own.
+type Strategy map[string]*Rule
+
+// Create a strategy from config data, in which each member
+// name is a pointer and each value is either a mode, or an
+// object with "mode" and "key" members.
+func NewStrategy(data map[string]interface{}) (Strategy, error) {
+	s := make(Strategy, len(data))
+
+	for p, v := range data {
+		r := &Rule{}
+
+		switch t := v.(type) {
+		case string:
+			r.Mode = t
+		case map[string]interface{}:
+			r.Mode, _ = t["mode"].(string)
+			r.Key, _ = t["key"].(string)
+		default:
+			return nil, fmt.Errorf("invalid rule for %q: %v", p, v)
+		}
+
+		if r.Mode == "" {
+			r.Mode = ModeMerge
+		} else if !IsMode(r.Mode) {
+			return nil, fmt.Errorf("unknown merge mode for %q: %q", p, r.Mode)
+		}
+
+		if p = strings.Trim(p, "/"); p != "" {
+			p = "/" + p
+		}
+
+		s[p] = r
+	}
+
+	return s, nil
+}
+
+func ReadStrategyBytes(d []byte, p string, t ...string) (Strategy, error) {
+	if file, err := ReadBytes(d, p, t...); err != nil {
+		return nil, err
+	} else {
+		return NewStrategy(file.Data)
+	}
+}
+
+func ReadStrategyFile(p string, t ...string) (Strategy, error) {
+	if file, err := ReadFile(p, t...); err != nil {
+		return nil, err
+	} else {
+		return NewStrategy(file.Data)
+	}
+}
+
+// Is m one of the merge modes?
+func IsMode(m string) bool {
+	for _, mode := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge src into dst and return the result. Maps in dst may
+// be modified in place.
+func (r *Rule) Merge(dst, src interface{}) interface{} {
+	return Strategy(nil).Merge(dst, src, r)
+}
+
+// Merge src into dst using the rules in the strategy, and r
+// for values that have none. Maps in dst may be modified in
+// place.
+func (s Strategy) Merge(dst, src interface{}, r *Rule) interface{} {
+	return s.merge("", dst, src, r)
+}
+
+// Returns the rule for pointer p, or r if there is none.
+func (s Strategy) rule(p string, r *Rule) *Rule {
+	if sr, ok := s[p]; ok {
+		return sr
+	}
+	return r
+}
+
+func (s Strategy) merge(p string, dst, src interface{}, r *Rule) interface{} {
+	if r = s.rule(p, r); dst == nil || r.Mode == ModeOverride {
+		return src
+	}
+
+	switch t := src.(type) {
+	case map[string]interface{}:
+		if d, ok := dst.(map[string]interface{}); ok {
+			if d == nil {
+				return t
+			}
+
+			for k, v := range t {
+				d[k] = s.merge(p+"/"+k, d[k], v, r)
+			}
+
+			return d
+		}
+	case []interface{}:
+		if d, ok := dst.([]interface{}); ok {
+			return s.mergeArray(p, d, t, r)
+		}
+	}
+
+	if r.Mode == ModeSet {
+		return dst
+	}
+
+	return src
+}
+
+func (s Strategy) mergeArray(p string, dst, src []interface{}, r *Rule) []interface{} {
+	if r.Key != "" {
+		src = s.mergeByKey(p, dst, src, r)
+	} else if r.Mode == ModeSet {
+		return dst
+	} else if r.Mode == ModeMerge {
+		return src
+	}
+
+	switch r.Mode {
+	case ModeAppendUnique:
+		return append(dst, missing(dst, src)...)
+	case ModePrepend:
+		return append(missing(dst, src), dst...)
+	case ModeUnion:
+		return missing(nil, append(dst, src...))
+	}
+
+	return append(dst, src...)
+}
+
+// Merge elements of src into the elements of dst that have
+// the same key, and return the elements that have none.
+func (s Strategy) mergeByKey(p string, dst, src []interface{}, r *Rule) (rest []interface{}) {
+	for _, v := range src {
+		if i := indexByKey(dst, v, r.Key); i >= 0 {
+			dst[i] = s.merge(fmt.Sprintf("%s/%d", p, i), dst[i], v, r)
+		} else {
+			rest = append(rest, v)
+		}
+	}
+
+	return
+}
+
+// Returns the elements of src that are neither in dst nor
+// repeated in src.
+func missing(dst, src []interface{}) (m []interface{}) {
+	for _, v := range src {
+		if index(dst, v) < 0 && index(m, v) < 0 {
+			m = append(m, v)
+		}
+	}
+
+	return
+}
+
+// Returns the index of the first element in s equal to v,
+// or -1 if there is none.
+func index(s []interface{}, v interface{}) int {
+	for i, e := range s {
+		if Equal(e, v) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Returns the index of the object in s that has the same
+// key field as v, or -1 if none does.
+func indexByKey(s []interface{}, v interface{}, key string) int {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return -1
+	}
+
+	k, ok := m[key]
+	if !ok {
+		return -1
+	}
+
+	for i, e := range s {
+		if o, ok := e.(map[string]interface{}); ok {
+			if w, ok := o[key]; ok && Equal(w, k) {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// Are a and b deeply equal config values? Unlike
+// reflect.DeepEqual, numbers are compared by value, so
+// that data read from JSON and YAML files can be compared.
+func Equal(a, b interface{}) bool {
+	switch x := a.(type) {
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for k, v := range x {
+			if w, ok := y[k]; !ok || !Equal(v, w) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+
+		for i := range x {
+			if !Equal(x[i], y[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	r := reflect.ValueOf(v)
+
+	switch r.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(r.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(r.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return r.Float(), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,230 @@
+package cfg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Returned when a source's path matches nothing in the data
+// it is merged into.
+var ErrNoMatch = errors.New("path matches nothing")
+
+// Is p a path pattern, i.e. does it contain a "*" wildcard
+// or a "[field=value]" selector segment?
+func IsPattern(p string) bool {
+	for _, s := range splitPath(p) {
+		if !isLiteral(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Split path p into its parent path and its last segment.
+func SplitPath(p string) (dir, base string) {
+	s := splitPath(p)
+
+	if len(s) == 0 {
+		return "", ""
+	}
+
+	n := len(s) - 1
+	dir = strings.Join(s[:n], "/")
+
+	if dir != "" {
+		dir = "/" + dir
+	}
+
+	return dir, s[n]
+}
+
+// Is s a literal path segment, i.e. neither a wildcard nor a
+// selector?
+func isLiteral(s string) bool {
+	return s != "*" && !isSelector(s)
+}
+
+func isSelector(s string) bool {
+	return len(s) > 2 && s[0] == '[' && s[len(s)-1] == ']'
+}
+
+// Split a path into segments. Slashes inside selectors do not
+// separate segments.
+func splitPath(p string) (segs []string) {
+	var (
+		b     strings.Builder
+		depth = 0
+	)
+
+	for _, c := range strings.Trim(p, "/") {
+		switch {
+		case c == '[':
+			depth++
+		case c == ']' && depth > 0:
+			depth--
+		case c == '/' && depth == 0:
+			segs = append(segs, b.String())
+			b.Reset()
+			continue
+		}
+		b.WriteRune(c)
+	}
+
+	if b.Len() > 0 {
+		segs = append(segs, b.String())
+	}
+
+	return
+}
+
+// Expand path pattern p to the pointers of all matching
+// values in data.
+//
+// A "*" segment matches all members of an object or all
+// elements of an array. A "[field=value]" segment matches
+// those that are objects whose field has the given value.
+// Other segments match an object member by name, or an array
+// element by index. If the last segment is a name, it also
+// matches missing members of objects.
+func Match(data interface{}, p string) ([]string, error) {
+	var res []string
+
+	err := match(data, "", splitPath(p), &res)
+
+	return res, err
+}
+
+func match(v interface{}, p string, segs []string, res *[]string) error {
+	if len(segs) == 0 {
+		*res = append(*res, p)
+		return nil
+	}
+
+	seg, rest := segs[0], segs[1:]
+
+	switch {
+	case seg == "*":
+		for _, k := range members(v) {
+			if err := match(member(v, k), p+"/"+k, rest, res); err != nil {
+				return err
+			}
+		}
+	case isSelector(seg):
+		kv := strings.SplitN(seg[1:len(seg)-1], "=", 2)
+
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid selector: %s", seg)
+		}
+
+		for _, k := range members(v) {
+			m := member(v, k)
+
+			if o, ok := m.(map[string]interface{}); ok {
+				if f, ok := o[kv[0]]; ok && fmt.Sprint(f) == kv[1] {
+					if err := match(m, p+"/"+k, rest, res); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	default:
+		if m := member(v, seg); m != nil {
+			return match(m, p+"/"+seg, rest, res)
+		} else if _, ok := v.(map[string]interface{}); ok && len(rest) == 0 {
+			*res = append(*res, p+"/"+seg)
+		}
+	}
+
+	return nil
+}
+
+// Returns the member names of an object in sorted order, or
+// the indices of an array.
+func members(v interface{}) (keys []string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+	case []interface{}:
+		for i := range t {
+			keys = append(keys, strconv.Itoa(i))
+		}
+	}
+	return
+}
+
+// Returns the member of an object, or the element of an
+// array, named k, or nil if there is none.
+func member(v interface{}, k string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return t[k]
+	case []interface{}:
+		if i, err := strconv.Atoi(k); err == nil && i >= 0 && i < len(t) {
+			return t[i]
+		}
+	}
+	return nil
+}
+
+// Returns the value at pointer p in data, or nil if there is
+// none.
+func Get(data interface{}, p string) interface{} {
+	for _, k := range splitPath(p) {
+		if data = member(data, k); data == nil {
+			break
+		}
+	}
+	return data
+}
+
+// Set the value at pointer p in data and return the updated
+// data. The parent of the value must exist.
+func Set(data interface{}, p string, v interface{}) (interface{}, error) {
+	dir, base := SplitPath(p)
+
+	if base == "" {
+		return v, nil
+	}
+
+	switch t := Get(data, dir).(type) {
+	case map[string]interface{}:
+		t[base] = v
+	case []interface{}:
+		i, err := strconv.Atoi(base)
+
+		if err != nil || i < 0 || i >= len(t) {
+			return data, fmt.Errorf("invalid array index: %s", p)
+		}
+
+		t[i] = v
+	default:
+		return data, fmt.Errorf("cannot set member: %s", p)
+	}
+
+	return data, nil
+}
+
+// Returns a deep copy of config value v.
+func Copy(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = Copy(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, e := range t {
+			s[i] = Copy(e)
+		}
+		return s
+	}
+	return v
+}
@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime/debug"
 	"strings"
 
-	. "github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/arg"
+
 	. "github.com/makeblank/blank/std"
 )
 
@@ -15,6 +17,11 @@ const (
 	BlankCommandName = "blank"
 	vBLANK_PATH      = "BLANK_PATH"
 	vBLANK           = "BLANK"
+	vVPATH           = "VPATH"
+	vBLANK_CHAIN     = "BLANK_CHAIN"
+	vBLANK_MAX_DEPTH = "BLANK_MAX_DEPTH"
+	vBLANK_TRACE     = "BLANK_TRACE"
+	vBLANK_SOURCE    = "BLANK_SOURCE"
 )
 
 const blankCommandHelp = `
@@ -64,46 +71,41 @@ func (c *BlankCommand) Flags() []*Flag {
 
 func (c *BlankCommand) Run(args []string) error {
 	var (
-		err error
-		a   string
+		err  error
+		a    string
+		opts struct {
+			Append []string `flag:"a"`
+			Paths  string   `flag:"P"`
+		}
 	)
 
-	for len(args) > 0 {
-		if a, args = NextFlag(args, "-aP"); Empty(a) {
-			break
-		}
+	// other flags are passed to the default command
+	if args, err = arg.ParseKnownFlags(c.flags, args, &opts); err != nil {
+		return err
+	}
 
-		f := a
+	if arg.Ok(opts.Paths) {
+		blankPaths = filepath.SplitList(opts.Paths)
+	}
 
-		if a, args = NextArg(args); Ok(a) {
-			if f == "-a" {
-				addBlankPath(a)
-			} else {
-				blankPaths = filepath.SplitList(a)
-			}
-		} else {
-			err = ArgRequiredError(f)
-		}
+	for _, p := range opts.Append {
+		addBlankPath(p)
 	}
 
 	exportBlankPath()
 
-	if err == nil {
-		if a, args = NextArg(args); Ok(a) {
-			if cmd := c.findSubcommand(a); cmd != nil {
-				RunWithHelp(cmd, args, c.Name())
-				return nil
-			}
-
-			args = append([]string{a}, args...)
+	if a, args = arg.NextArg(args); arg.Ok(a) {
+		if cmd := c.findSubcommand(a); cmd != nil {
+			RunWithHelp(cmd, args, c.Name())
+			return nil
 		}
 
-		// 'make' as default command
-		RunWithHelp(Make, args, c.Name())
-		return nil
+		args = append([]string{a}, args...)
 	}
 
-	return err
+	// 'make' as default command
+	RunWithHelp(Make, args, c.Name())
+	return nil
 }
 
 func (c *BlankCommand) findSubcommand(name string) Command {
@@ -125,17 +127,51 @@ var Blank = &BlankCommand{
 	},
 
 	[]*Flag{
-		{Name: "-a", Desc: fmt.Sprintf("append `path` to %s", vBLANK_PATH)},
-		{Name: "-P", Desc: fmt.Sprintf("set `paths` as %s", vBLANK_PATH)},
+		{
+			Name:   "-a",
+			Desc:   fmt.Sprintf("append `path` to %s", vBLANK_PATH),
+			Type:   arg.FlagString,
+			Repeat: true,
+		},
+		{
+			Name: "-P",
+			Desc: fmt.Sprintf("set `paths` as %s", vBLANK_PATH),
+			Type: arg.FlagString,
+		},
 	},
 
 	[]Command{
 		Make,
 		Update,
+		Render,
+		List,
+		Which,
+		Rerun,
+		Status,
+		Upgrade,
+		Test,
+		Undo,
 		Help,
 	},
 }
 
+// The version of this program. It may be set when building,
+// with -ldflags "-X github.com/makeblank/blank/cmd.Version=v1.0.0",
+// or else it is the version of the main module.
+var Version string
+
+func version() string {
+	if arg.Ok(Version) {
+		return Version
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+
+	return ""
+}
+
 // Run the main "blank" program.
 func Main(args []string) {
 	RunWithHelp(Blank, args)
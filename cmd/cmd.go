@@ -1,21 +1,21 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/blk"
+
 	. "github.com/makeblank/blank/std"
 )
 
-// Encapsulates only "informational" details about a flag.
-// Used by WriteFlagUsage and WriteCommandUsage to display
-// the help screen.
-type Flag struct {
-	Name string // The flag names
-	Desc string // The flag description.
-}
+// A command-line flag, shown in help screens and parsed by
+// arg.ParseFlags.
+type Flag = arg.Flag
 
 // A uniquely named command.
 type Named interface {
@@ -107,14 +107,109 @@ func WriteCommandUsage(w io.Writer, c Command, p ...string) {
 	}
 }
 
-// Write a flag's usage line.
+// Write a target's help screen, from its documentation.
+func WriteTargetUsage(w io.Writer, b *blk.Blank) error {
+	doc, err := b.ReadDoc()
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Usage: %s %s [name=value]...\n", BlankCommandName, b.Name)
+
+	if doc.Desc != "" {
+		fmt.Fprint(w, "\n", doc.Desc, "\n")
+	}
+
+	if len(doc.Params) > 0 {
+		fmt.Fprintln(w, "\nVariables:")
+	}
+
+	for _, p := range doc.Params {
+		var details []string
+
+		if p.Type != "" && p.Type != blk.TypeString {
+			details = append(details, p.Type)
+		}
+
+		if len(p.Enum) > 0 {
+			details = append(details, strings.Join(p.Enum, "|"))
+		}
+
+		if p.Required {
+			details = append(details, "required")
+		}
+
+		if p.Default != "" {
+			details = append(details, "default: "+p.Default)
+		}
+
+		desc := p.Desc
+
+		if len(details) > 0 {
+			desc = fmt.Sprintf("%s (%s)", desc, strings.Join(details, ", "))
+		}
+
+		fmt.Fprintf(w, FlagLineFormat, p.Name, strings.TrimSpace(desc))
+	}
+
+	writeList(w, "Examples", doc.Examples)
+	writeList(w, "Files", doc.Files)
+
+	fmt.Fprintf(w, "\nSource: %s\n", b.Path)
+
+	return nil
+}
+
+func writeList(w io.Writer, title string, items []string) {
+	if len(items) > 0 {
+		fmt.Fprintf(w, "\n%s:\n", title)
+	}
+
+	for _, i := range items {
+		fmt.Fprintf(w, "  %s\n", i)
+	}
+}
+
+// Write a flag's usage line, with the values it may have, its
+// default and variable, and whether it is required.
 func WriteFlagUsage(w io.Writer, f *Flag) {
+	var details []string
+
 	word, usage := UnquoteUsage(f.Desc)
+
+	if Empty(word) && f.HasValue() {
+		word = f.Type
+	}
+
+	if len(f.Enum) > 0 {
+		details = append(details, strings.Join(f.Enum, "|"))
+	}
+
+	if f.Required {
+		details = append(details, "required")
+	}
+
+	if f.Default != "" {
+		details = append(details, "default: "+f.Default)
+	}
+
+	if f.Env != "" {
+		details = append(details, "env: $"+f.Env)
+	}
+
+	if len(details) > 0 {
+		usage = fmt.Sprintf("%s (%s)", usage, strings.Join(details, ", "))
+	}
+
 	flag := fmt.Sprintf("%s %s", f.Name, word)
 	fmt.Fprintf(w, FlagLineFormat, flag, usage)
 }
 
 // Run command c, intercepting first -h or --help argument.
+// If it fails, the error is shown, followed by the command's
+// usage if the error is in its arguments (see
+// arg.OptionError), and the program exits.
 func RunWithHelp(c Command, args []string, p ...string) {
 	for _, a := range args {
 		if a == "-h" || a == "--help" {
@@ -127,8 +222,14 @@ func RunWithHelp(c Command, args []string, p ...string) {
 
 	if err := c.Run(args); err != nil {
 		WriteError(err)
-		os.Stdout.WriteString("\n")
-		WriteCommandUsage(os.Stdout, c)
+
+		var oerr *arg.OptionError
+
+		if errors.As(err, &oerr) {
+			os.Stdout.WriteString("\n")
+			WriteCommandUsage(os.Stdout, c)
+		}
+
 		os.Exit(1)
 	}
 }
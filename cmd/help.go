@@ -3,6 +3,8 @@ package cmd
 import (
 	"os"
 
+	"github.com/makeblank/blank/blk"
+
 	. "github.com/makeblank/blank/std"
 )
 
@@ -31,6 +33,10 @@ func (c *HelpCommand) Run(args []string) error {
 			WriteCommandUsage(os.Stdout, cmd, Blank.Name())
 			return nil
 		}
+
+		if b, _ := blk.Find(a, targetPaths()); b != nil {
+			return WriteTargetUsage(os.Stdout, b)
+		}
 	}
 
 	WriteCommandUsage(os.Stdout, Blank)
@@ -41,7 +47,7 @@ func (c *HelpCommand) Run(args []string) error {
 // The default "help" subcommand instance.
 var Help = &HelpCommand{
 	info: &Info{
-		Line: "%s [command]",
+		Line: "%s [command | target]",
 		Desc: "Show help screen.",
 	},
 }
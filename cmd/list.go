@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/makeblank/blank/blk"
+)
+
+const ListCommandName = "list"
+
+const listCommandHelp = `
+Every directory in %s is searched for "[target].mk"
+makefiles, "[target].sh" scripts, "[target].blank.yaml"
+recipes, executable "[target]" files and "[target]/"
+template directories, in order. A target's description is
+the first line of the "description" of its manifest, or
+else the first "## " comment at the top of its file.
+Targets marked as hidden are never used, because a target
+with the same name is listed earlier: in a directory listed
+earlier, or in the same directory with a file that comes
+first in the order above.
+`
+
+// The "list" subcommand type.
+type ListCommand struct {
+	info *Info
+	NoFlags
+}
+
+func (c *ListCommand) Name() string {
+	return ListCommandName
+}
+
+func (c *ListCommand) Info() *Info {
+	return c.info
+}
+
+func (c *ListCommand) Help() string {
+	return fmt.Sprintf(listCommandHelp, vBLANK_PATH)
+}
+
+func (c *ListCommand) Run(args []string) error {
+	list, err := blk.List(targetPaths())
+
+	if err != nil {
+		return err
+	}
+
+	dir := ""
+
+	for _, b := range list {
+		if b.Dir != dir {
+			if dir != "" {
+				fmt.Println()
+			}
+
+			dir = b.Dir
+			fmt.Printf("%s:\n", dir)
+		}
+
+		desc := b.Desc
+
+		if b.Hidden {
+			desc = fmt.Sprintf("(hidden) %s", desc)
+		}
+
+		fmt.Printf(FlagLineFormat, b.Name, desc)
+	}
+
+	return nil
+}
+
+// The default "list" subcommand instance.
+var List = &ListCommand{
+	info: &Info{
+		Line: "%s",
+		Desc: "List targets found in BLANK_PATH.",
+	},
+}
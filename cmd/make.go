@@ -1,29 +1,71 @@
 package cmd
 
 import (
-	_ "embed"
+	"bufio"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/blk"
+	"github.com/makeblank/blank/out"
+	"github.com/makeblank/blank/proj"
+	"github.com/makeblank/blank/tpl"
 
 	. "github.com/makeblank/blank/std"
 )
 
-//go:embed make.mk
-var makefile []byte
-
 const MakeCommandName = "make"
 
 const makeCommandHelpFmt = `
-Target is searched for in directories in %[1]s. The
-first file named "[target].mk" found is used as the makefile
-(passed as the "-f" make option.)
+Target is searched for in directories in %[1]s, a list
+of paths separated by %[2]q. Empty entries are ignored and
+a leading "~" is expanded to the user's home directory. The
+first file found for target is run with its engine:
+
+  [target].mk          a makefile, run by make ("-f"), with
+                       other targets as its goals
+  [target].sh          a script, run with its "#!" line or sh
+  [target].blank.yaml  a recipe, whose steps are run in order
+  [target]             an executable, run directly
+  [target]/            a template directory, rendered into
+                       the working directory
+
+Each directory in %[1]s is also an "--include-dir" make
+option, and is added to the VPATH make variable. Blanks get
+"name=value" arguments as variables, and the path of their
+own file as %[7]s. Makefiles may run blank recursively as
+$(%[4]s). A run of a target by one of its own nested runs,
+or nested deeper than %[5]s (default %[6]d), fails.
+
+If a "-h" or "--help" option follows target, the target's
+documentation is shown instead, from its manifest
+"[target].yaml" or the "##" comments at the top of its file.
+The parameters that the manifest declares are checked, and
+missing ones are prompted for if stdin is a terminal, unless
+the "--no-input" option is given or CI is set. The targets
+it depends on are run first.
+
+Existing files that templates and recipes would change get
+a conflict policy p: skip, overwrite, prompt, or new (write
+"[file].blank-new"), from the "--conflict" option or the
+manifest, or else prompt if stdin is a terminal, and new
+otherwise.
 
-%[1]s is a list of paths separated by %[2]q. Each
-directory in %[1]s is used in two additional ways:
-1. as an "--include-dir" make option, and 2. as the VPATH
-make variable.
+At the end, blank reports the files that the run changed,
+or writes the report as JSON to the "--json" file ("-" for
+stderr). The "--preview" option shows the changes instead of
+making them. A successful run is recorded in %[3]s
+(see "blank rerun" and "blank status"), and its changes in
+the project's journal (see "blank undo").
+
+See the README for details on templates, recipes, manifests
+and reports.
 
 All other options are passed directly to the 'make' program.
 Run 'make --help' for additional options.
@@ -33,13 +75,71 @@ var makeCommandHelp = fmt.Sprintf(
 	makeCommandHelpFmt,
 	vBLANK_PATH,
 	filepath.ListSeparator,
+	filepath.Join(proj.Dir, proj.AnswersFile),
+	vBLANK,
+	vBLANK_MAX_DEPTH,
+	defaultMaxDepth,
+	vBLANK_SOURCE,
 )
 
+// Make options that take a separate argument.
+var makeArgOptions = []string{
+	"-C", "--directory",
+	"-f", "--file", "--makefile",
+	"-I", "--include-dir",
+	"-o", "--old-file", "--assume-old",
+	"-W", "--what-if", "--new-file", "--assume-new",
+	"--eval",
+}
+
+// Make options that take an optional numeric argument.
+var makeNumOptions = []string{
+	"-j", "--jobs",
+	"-l", "--load-average", "--max-load",
+}
+
 // The "make" subcommand type.
 type MakeCommand struct {
-	info *Info
+	info  *Info
+	flags []*Flag
+}
+
+// Options of the make command, which are not passed to make.
+type makeOptions struct {
+	NoInput  bool   `flag:"no-input"`
+	Trace    bool   `flag:"trace"`
+	Preview  bool   `flag:"preview"`
+	JSON     string `flag:"json"` // The file of the JSON report, "-" for stderr.
+	Conflict string `flag:"conflict"`
+}
+
+// Returns the arguments that give options o to the make
+// command, other than "--preview", with the path of the JSON
+// report made absolute.
+func (o *makeOptions) args() []string {
+	var args []string
+
+	if o.NoInput {
+		args = append(args, "--no-input")
+	}
 
-	NoFlags
+	if o.Trace {
+		args = append(args, "--trace")
+	}
+
+	if p := o.JSON; arg.Ok(p) {
+		if abs, err := filepath.Abs(p); err == nil && p != "-" {
+			p = abs
+		}
+
+		args = append(args, "--json", p)
+	}
+
+	if arg.Ok(o.Conflict) {
+		args = append(args, "--conflict", o.Conflict)
+	}
+
+	return args
 }
 
 func (c *MakeCommand) Name() string {
@@ -54,21 +154,28 @@ func (c *MakeCommand) Help() string {
 	return makeCommandHelp
 }
 
+func (c *MakeCommand) Flags() []*Flag {
+	return c.flags
+}
+
 func (c *MakeCommand) Run(args []string) error {
-	// args passed to make:
-	//   -s Silent operation
-	//   -E Eval implicit makefile
-	args = append([]string{"-s", "-E", string(makefile)}, args...)
+	opts, rest, err := parseMakeOptions(args)
 
-	cmd := exec.Command("make", args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	if err != nil {
+		return err
+	}
+
+	target, vars, margs := splitMakeArgs(rest)
 
-	ExecCmd(cmd)
+	if Empty(target) {
+		return arg.ArgRequiredError("target")
+	}
 
-	// NOTE: ExecCmd must block and exit itself
-	panic("ExecCmd did not exit")
+	if opts.Preview {
+		return previewTarget(os.Stdout, append(opts.args(), rest...))
+	}
+
+	return runTarget(opts, target, vars, margs)
 }
 
 // The default "make" subcommand instance.
@@ -77,4 +184,563 @@ var Make = &MakeCommand{
 		Line: "%s [options] [target] ...",
 		Desc: "Generate blank dev projects using makefiles.",
 	},
+
+	flags: []*Flag{
+		{Name: "--no-input", Desc: "never prompt for parameters"},
+		{Name: "--trace", Desc: "show tree of nested runs"},
+		{Name: "--preview", Desc: "show changes without making them"},
+		{
+			Name: "--json",
+			Desc: "write the report of changes as JSON to `file`",
+			Type: arg.FlagString,
+		},
+		{
+			Name: "--conflict",
+			Desc: "apply policy `p` to existing files",
+			Type: arg.FlagString,
+			Enum: out.Policies,
+		},
+	},
+}
+
+// Parse the make command's own options in args, up to the
+// target, and return the other arguments. Options of make
+// and variables before the target are skipped, and options
+// after the target are left in args, to be passed to make or
+// to the target's engine.
+func parseMakeOptions(args []string) (o *makeOptions, rest []string, err error) {
+	o = &makeOptions{}
+	rest = make([]string, 0, len(args))
+
+	for {
+		if args, err = arg.ParseKnownFlags(Make.flags, args, o); err != nil {
+			return nil, nil, err
+		}
+
+		if len(args) == 0 {
+			return
+		}
+
+		a := args[0]
+
+		if !arg.IsAFlag(a) && !strings.Contains(a, "=") {
+			return o, append(rest, args...), nil
+		}
+
+		rest, args = append(rest, a), args[1:]
+
+		if len(args) == 0 {
+			return
+		}
+
+		// skip the arguments of make options
+		if arg.IsWord(a, makeArgOptions...) {
+			rest, args = append(rest, args[0]), args[1:]
+		} else if _, err := strconv.Atoi(args[0]); err == nil && arg.IsWord(a, makeNumOptions...) {
+			rest, args = append(rest, args[0]), args[1:]
+		}
+	}
+}
+
+// Find target and run it with its engine, given variables
+// and other arguments, then record the run in the project's
+// answers file.
+//
+// NOTE: if the run fails, this program exits with its status,
+// after the run is added to the journal.
+func runTarget(o *makeOptions, target string, vars, args []string) error {
+	paths := targetPaths()
+	b, cands := blk.Find(target, paths)
+
+	if b == nil {
+		return targetNotFoundError(target, cands)
+	}
+
+	for _, a := range args {
+		if arg.IsWord(a, "-h", "--help") {
+			return WriteTargetUsage(os.Stdout, b)
+		}
+	}
+
+	plan, err := blk.Plan(b, paths)
+
+	if err != nil {
+		return err
+	}
+
+	for _, s := range plan {
+		if err = checkChain(s.Blank.Name); err != nil {
+			return err
+		}
+	}
+
+	var (
+		given  = map[string][]string{b.Name: vars}
+		params = make(map[string]map[string]string, len(plan))
+	)
+
+	// resolve parameters of blanks before those of their
+	// dependencies, which may be given values of them
+	for i := len(plan) - 1; i >= 0; i-- {
+		var (
+			s    = plan[i]
+			name = s.Blank.Name
+		)
+
+		params[name], given[name], err = resolveParams(
+			s.Blank,
+			given[name],
+			canPrompt(o),
+		)
+
+		if err != nil {
+			return err
+		}
+
+		vals := varValues(given[name])
+
+		for _, d := range s.Depends {
+			if given[d.Name], err = addVars(given[d.Name], d.Expand(vals)); err != nil {
+				return fmt.Errorf("%w (required by %s)", err, name)
+			}
+		}
+	}
+
+	var (
+		code int
+		done = startJournal(b.Name)
+		stop = startTrace(o.Trace)
+		w    = newWriter(o)
+	)
+
+	for _, s := range plan {
+		name := s.Blank.Name
+		a := given[name]
+
+		if s.Blank == b {
+			a = append(args, a...)
+		}
+
+		if w.Policy, err = conflictPolicy(o, s.Blank); err != nil {
+			break
+		}
+
+		if code = runBlank(s.Blank, paths, params[name], a, w); code != 0 {
+			break
+		}
+	}
+
+	stop()
+
+	if err == nil && code == 0 {
+		err = recordRun(b, given[b.Name])
+	}
+
+	if changes, ok := done(); ok || arg.Ok(o.JSON) {
+		report := newRunReport(b.Name, code, changes, w)
+
+		if !arg.Ok(o.JSON) {
+			report.Write(os.Stderr)
+		} else if jerr := writeJSONReport(report, o.JSON); jerr != nil {
+			WriteError(fmt.Errorf("Cannot write the report: %w", jerr))
+
+			if code == 0 {
+				code = 1
+			}
+		}
+	} else {
+		w.WriteSummary(os.Stderr)
+	}
+
+	if code != 0 {
+		os.Exit(code)
+	}
+
+	return err
+}
+
+// Run blank b with its engine, and add the run to the trace.
+// Files that blank writes itself are written with w.
+func runBlank(
+	b *blk.Blank,
+	paths []string,
+	params map[string]string,
+	args []string,
+	w *out.Writer,
+) int {
+	var (
+		code  int
+		start = time.Now()
+	)
+
+	switch b.Engine {
+	case blk.EngineTemplate:
+		code = renderBlank(b, args, w)
+	case blk.EngineRecipe:
+		code = runRecipe(b, paths, params, args, w)
+	default:
+		code = RunCmd(blankCmd(b, paths, params, args))
+	}
+
+	traceRun(b.Name, start, code)
+
+	return code
+}
+
+// Record a run of blank b with the given variables in the
+// answers file of the project in the working directory,
+// unless the run is nested in another one.
+func recordRun(b *blk.Blank, vars []string) error {
+	if os.Getenv(vBLANK_CHAIN) != "" {
+		return nil
+	}
+
+	answers, err := proj.ReadAnswers(".")
+
+	if err != nil {
+		return err
+	}
+
+	hash, err := b.Hash()
+
+	if err != nil {
+		return err
+	}
+
+	answers.Add(&proj.Run{
+		Target:  b.Name,
+		Source:  b.Path,
+		Hash:    hash,
+		Params:  varValues(vars),
+		Time:    time.Now(),
+		Version: version(),
+	})
+
+	return answers.Write()
+}
+
+// Create the writer of the files that blanks write, which
+// prompts the user if they can be prompted.
+func newWriter(o *makeOptions) *out.Writer {
+	w := out.New(o.Conflict)
+
+	if canPrompt(o) {
+		w.In = bufio.NewReader(os.Stdin)
+		w.Out = os.Stderr
+	}
+
+	return w
+}
+
+// Returns the conflict policy of the run of blank b: the one
+// given as option, or else the one in its manifest, or else
+// out.PolicyPrompt if the user can be prompted, or else
+// out.PolicyNew.
+func conflictPolicy(o *makeOptions, b *blk.Blank) (string, error) {
+	if arg.Ok(o.Conflict) {
+		return o.Conflict, nil
+	}
+
+	if doc, err := b.ReadManifest(); err != nil {
+		return "", err
+	} else if doc != nil && doc.Conflict != "" {
+		return doc.Conflict, nil
+	}
+
+	if canPrompt(o) {
+		return out.PolicyPrompt, nil
+	}
+
+	return out.PolicyNew, nil
+}
+
+// Returns the directories searched for targets.
+func targetPaths() []string {
+	return blk.CleanPaths(blankPaths)
+}
+
+func targetNotFoundError(target string, cands []*blk.Candidate) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "No target found: %s", target)
+
+	if len(cands) > 0 {
+		b.WriteString("\nSearched:")
+	}
+
+	for _, c := range cands {
+		fmt.Fprintf(&b, "\n  %s", c.Path)
+	}
+
+	return fmt.Errorf("%s", b.String())
+}
+
+// Check the values of variables declared as parameters in
+// the manifest of blank b, if it has one. If prompt is true
+// and required parameters are missing, the user is prompted
+// for all missing parameters. Returns the values of all
+// parameters, and vars with assignments of prompted values
+// and defaults for missing parameters appended.
+func resolveParams(
+	b *blk.Blank,
+	vars []string,
+	prompt bool,
+) (map[string]string, []string, error) {
+	doc, err := b.ReadManifest()
+
+	if err != nil || doc == nil {
+		return nil, vars, err
+	}
+
+	vals := varValues(vars)
+
+	if prompt && len(doc.Missing(vals)) > 0 {
+		in := bufio.NewReader(os.Stdin)
+
+		for _, p := range doc.Params {
+			if _, ok := vals[p.Name]; ok {
+				continue
+			}
+
+			if v, err := promptParam(in, os.Stderr, p); err != nil {
+				return nil, nil, err
+			} else if v != "" {
+				vals[p.Name] = v
+				vars = append(vars, p.Name+"="+v)
+			}
+		}
+	}
+
+	res, err := doc.Resolve(vals)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	params := make(map[string]string, len(doc.Params))
+
+	for _, p := range doc.Params {
+		if v, ok := res[p.Name]; ok {
+			params[p.Name] = v
+
+			if _, given := vals[p.Name]; !given {
+				vars = append(vars, p.Name+"="+v)
+			}
+		}
+	}
+
+	return params, vars, nil
+}
+
+// Render the template directory of blank b into the working
+// directory, with the values of "name=value" arguments as
+// data, typed as the manifest declares them (see Doc.Data).
+// Other arguments are ignored.
+func renderBlank(b *blk.Blank, args []string, w *out.Writer) int {
+	doc, err := b.ReadDoc()
+
+	if err == nil {
+		_, err = tpl.RenderDir(b.Path, ".", doc.Data(varValues(args)), doc.Verbatim, w)
+	}
+
+	if err != nil {
+		WriteError(err)
+		return 1
+	}
+
+	return 0
+}
+
+// Create the command that runs blank b with its engine. A
+// makefile is run by make with the given paths as include
+// dirs, and a shell script or an executable is run directly.
+// The paths are passed as VPATH, and parameters and other
+// "name=value" arguments as environment variables.
+func blankCmd(
+	b *blk.Blank,
+	paths []string,
+	params map[string]string,
+	args []string,
+) *exec.Cmd {
+	var (
+		cmd *exec.Cmd
+		env = blankEnv(b, paths, params)
+	)
+
+	switch b.Engine {
+	case blk.EngineShell:
+		sh := b.Interpreter()
+		env, args = scriptArgs(env, args)
+		cmd = exec.Command(sh[0], append(append(sh[1:], b.Path), args...)...)
+	case blk.EngineExec:
+		env, args = scriptArgs(env, args)
+		cmd = exec.Command(b.Path, args...)
+	default:
+		cmd = makeCmd(b, paths, args)
+	}
+
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd
+}
+
+// Returns the environment of the commands that blank b runs,
+// with the given paths as VPATH and parameters as variables.
+func blankEnv(b *blk.Blank, paths []string, params map[string]string) []string {
+	env := append(
+		os.Environ(),
+		vVPATH+"="+vpath(paths),
+		vBLANK_CHAIN+"="+chain(b.Name),
+		vBLANK_SOURCE+"="+b.Path,
+	)
+
+	for _, n := range sortedKeys(params) {
+		env = append(env, n+"="+params[n])
+	}
+
+	return env
+}
+
+// Returns the VPATH of blank runs: the existing VPATH, if
+// any, followed by the given paths that it does not have yet.
+func vpath(paths []string) string {
+	sep := string(filepath.ListSeparator)
+	dirs := filepath.SplitList(os.Getenv(vVPATH))
+
+	for _, p := range paths {
+		if !arg.IsWord(p, dirs...) {
+			dirs = append(dirs, p)
+		}
+	}
+
+	return strings.Join(dirs, sep)
+}
+
+// Create the command that runs make with blank b as the
+// makefile, the given paths as include dirs, and the given
+// make arguments.
+func makeCmd(b *blk.Blank, paths []string, args []string) *exec.Cmd {
+	a := []string{"--no-print-directory"}
+
+	for _, p := range paths {
+		a = append(a, "-I", p)
+	}
+
+	a = append(a, "-f", b.Path)
+	a = append(a, args...)
+
+	return exec.Command("make", a...)
+}
+
+// Move "name=value" arguments of a script to its environment.
+// The other arguments are passed as they are.
+func scriptArgs(env, args []string) ([]string, []string) {
+	rest := make([]string, 0, len(args))
+
+	for _, a := range args {
+		if n, v, ok := splitVar(a); ok {
+			env = append(env, n+"="+v)
+		} else {
+			rest = append(rest, a)
+		}
+	}
+
+	return env, rest
+}
+
+// Split make arguments into the target, i.e. the first goal,
+// variable assignments, and all other arguments, in order.
+func splitMakeArgs(args []string) (target string, vars, rest []string) {
+	rest = make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+
+		if !arg.IsAFlag(a) {
+			if strings.Contains(a, "=") {
+				vars = append(vars, a)
+			} else if Empty(target) {
+				target = a
+			} else {
+				rest = append(rest, a)
+			}
+			continue
+		}
+
+		rest = append(rest, a)
+
+		if i+1 == len(args) {
+			continue
+		}
+
+		if arg.IsWord(a, makeArgOptions...) {
+			i++
+			rest = append(rest, args[i])
+		} else if arg.IsWord(a, makeNumOptions...) {
+			if _, err := strconv.Atoi(args[i+1]); err == nil {
+				i++
+				rest = append(rest, args[i])
+			}
+		}
+	}
+
+	return
+}
+
+// Returns the name and value of a "name=value" variable
+// assignment, which is not ok if it uses another operator,
+// such as ":=" or "+=".
+func splitVar(a string) (name, value string, ok bool) {
+	nv := strings.SplitN(a, "=", 2)
+
+	if len(nv) != 2 || nv[0] == "" || strings.ContainsAny(nv[0], ":+?! \t") {
+		return "", "", false
+	}
+
+	return nv[0], nv[1], true
+}
+
+// Returns the values of all "name=value" variables.
+func varValues(vars []string) map[string]string {
+	vals := make(map[string]string, len(vars))
+
+	for _, v := range vars {
+		if n, v, ok := splitVar(v); ok {
+			vals[n] = v
+		}
+	}
+
+	return vals
+}
+
+// Add assignments of the given values to vars, unless vars
+// already has them. It is an error if vars assigns different
+// values.
+func addVars(vars []string, vals map[string]string) ([]string, error) {
+	prev := varValues(vars)
+
+	for _, n := range sortedKeys(vals) {
+		if v, ok := prev[n]; !ok {
+			vars = append(vars, n+"="+vals[n])
+		} else if v != vals[n] {
+			return nil, fmt.Errorf("Conflicting values of variable %s: %q, %q", n, v, vals[n])
+		}
+	}
+
+	return vars, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
 }
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/makeblank/blank/proj"
+	"github.com/makeblank/blank/snap"
+
+	. "github.com/makeblank/blank/std"
+)
+
+// Run the make command with the given arguments in a copy of
+// the working directory, and write the changes it made to w,
+// as a tree of files and a unified diff. The working directory
+// is left untouched.
+//
+// NOTE: if the run fails, this program exits with its status,
+// after the changes are written.
+func previewTarget(w io.Writer, args []string) error {
+	tmp, err := ioutil.TempDir("", "blank-preview-")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmp)
+
+	changes, code, err := runInCopy(".", tmp, args)
+
+	if err != nil {
+		return err
+	}
+
+	writeChanges(w, ".", tmp, changes)
+
+	if code != 0 {
+		os.RemoveAll(tmp)
+		os.Exit(code)
+	}
+
+	return nil
+}
+
+// Copy the files in dir into tmp, and run the make command
+// with the given arguments there. Returns the changes of the
+// run to the project's files, i.e. outside of proj.Dir, and
+// its exit status.
+func runInCopy(dir, tmp string, args []string) ([]*snap.Change, int, error) {
+	journal := filepath.ToSlash(proj.JournalPath())
+
+	if err := snap.Copy(dir, tmp, journal); err != nil {
+		return nil, 0, err
+	}
+
+	before, err := snap.Take(tmp, proj.Dir)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sep := string(filepath.ListSeparator)
+	cmd := exec.Command(os.Getenv(vBLANK), append([]string{MakeCommandName}, args...)...)
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(), vBLANK_PATH+"="+strings.Join(targetPaths(), sep))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	code := RunCmd(cmd)
+	after, err := snap.Take(tmp, proj.Dir)
+
+	if err != nil {
+		return nil, code, err
+	}
+
+	return snap.Compare(before, after), code, nil
+}
+
+// Write the tree of changes from the files in dir a to the
+// files in dir b, followed by their unified diff.
+func writeChanges(w io.Writer, a, b string, changes []*snap.Change) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "\nNo changes.")
+		return
+	}
+
+	fmt.Fprintln(w, "\nChanges:")
+	snap.WriteTree(w, changes)
+	fmt.Fprintln(w)
+
+	for _, c := range changes {
+		var (
+			aName = "a/" + c.Path
+			bName = "b/" + c.Path
+			aData []byte
+			bData []byte
+		)
+
+		if c.Kind == snap.Created {
+			aName = os.DevNull
+		} else {
+			aData, _ = ioutil.ReadFile(filepath.Join(a, c.Path))
+		}
+
+		if c.Kind == snap.Deleted {
+			bName = os.DevNull
+		} else {
+			bData, _ = ioutil.ReadFile(filepath.Join(b, c.Path))
+		}
+
+		fmt.Fprint(w, snap.Diff(aName, bName, aData, bData))
+	}
+}
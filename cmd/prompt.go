@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/blk"
+
+	. "github.com/makeblank/blank/std"
+)
+
+const vCI = "CI"
+
+// Is the standard input a terminal? A variable so that tests
+// can replace it.
+var stdinIsTerminal = func() bool {
+	return IsTerminal(os.Stdin)
+}
+
+// Can the user be prompted for input?
+func canPrompt(o *makeOptions) bool {
+	ci := os.Getenv(vCI)
+
+	if ci != "" && ci != "false" && ci != "0" {
+		return false
+	}
+
+	return !o.NoInput && stdinIsTerminal()
+}
+
+// Prompt for the value of parameter p until it is valid.
+// Returns the empty string to use the default, if p has one.
+func promptParam(in *bufio.Reader, out io.Writer, p *blk.Param) (string, error) {
+	if p.Desc != "" {
+		fmt.Fprintln(out, p.Desc)
+	}
+
+	for i, e := range p.Enum {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, e)
+	}
+
+	for {
+		if p.Default != "" {
+			fmt.Fprintf(out, "%s [%s]: ", p.Name, p.Default)
+		} else {
+			fmt.Fprintf(out, "%s: ", p.Name)
+		}
+
+		line, err := in.ReadString('\n')
+
+		if err == io.EOF && line == "" {
+			fmt.Fprintln(out)
+			return "", fmt.Errorf("no value for the %s parameter", p.Name)
+		} else if err != nil && err != io.EOF {
+			return "", err
+		}
+
+		v := strings.TrimSpace(line)
+
+		// a number is the index of an enum value, unless it is a value
+		if n, err := strconv.Atoi(v); err == nil && !arg.IsWord(v, p.Enum...) && n > 0 && n <= len(p.Enum) {
+			v = p.Enum[n-1]
+		}
+
+		if v == "" {
+			if p.Default != "" || !p.Required {
+				return "", nil
+			}
+			fmt.Fprintf(out, "  %s\n", &blk.ParamError{Name: p.Name, Desc: "is required"})
+		} else if err := p.Check(v); err != nil {
+			fmt.Fprintf(out, "  %s\n", err)
+		} else {
+			return v, nil
+		}
+	}
+}
+
+// Ask the user a yes or no question. Returns false unless the
+// answer is yes.
+func promptConfirm(in *bufio.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+
+	line, err := in.ReadString('\n')
+
+	if err == io.EOF && line == "" {
+		fmt.Fprintln(out)
+		return false, nil
+	} else if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/makeblank/blank/blk"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCanPrompt(t *testing.T) {
+	tests := []struct {
+		ci       string
+		noInput  bool
+		terminal bool
+		want     bool
+	}{
+		{"", false, true, true},
+		{"false", false, true, true},
+		{"0", false, true, true},
+		{"true", false, true, false},
+		{"1", false, true, false},
+		{"", true, true, false},
+		{"", false, false, false},
+	}
+
+	defer func(f func() bool) { stdinIsTerminal = f }(stdinIsTerminal)
+
+	for _, test := range tests {
+		t.Setenv(vCI, test.ci)
+		terminal := test.terminal
+		stdinIsTerminal = func() bool { return terminal }
+
+		got := canPrompt(&makeOptions{NoInput: test.noInput})
+		assert.Equal(t, got, test.want, "%+v", test)
+	}
+}
+
+func TestPromptParam(t *testing.T) {
+	var (
+		color = &blk.Param{Name: "color", Enum: []string{"red", "green"}}
+		port  = &blk.Param{Name: "port", Enum: []string{"8080", "2", "1"}}
+		name  = &blk.Param{Name: "name", Default: "demo"}
+		count = &blk.Param{Name: "count", Type: blk.TypeInt, Required: true}
+	)
+
+	tests := []struct {
+		param *blk.Param
+		input string
+		want  string
+		err   string
+	}{
+		// an enum value given by its index
+		{color, "2\n", "green", ""},
+		{color, "red\n", "red", ""},
+		// an enum value that looks like a number, or an index
+		{port, "2\n", "2", ""},
+		{port, "1\n", "1", ""},
+		{port, "3\n", "1", ""},
+		// the default on empty input
+		{name, "\n", "", ""},
+		{name, "web\n", "web", ""},
+		// a prompt again after invalid input
+		{color, "blue\n3\nred\n", "red", ""},
+		{count, "\nx\n7\n", "7", ""},
+		// the end of the input
+		{count, "", "", "no value for the count parameter"},
+		{count, "x\n", "", "no value for the count parameter"},
+		{count, "7", "7", ""},
+	}
+
+	for _, test := range tests {
+		in := bufio.NewReader(strings.NewReader(test.input))
+		got, err := promptParam(in, ioutil.Discard, test.param)
+
+		if test.err != "" {
+			assert.Error(t, err, test.err, "%q", test.input)
+		} else {
+			assert.NilError(t, err, "%q", test.input)
+			assert.Equal(t, got, test.want, "%q", test.input)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/makeblank/blank/blk"
+	"github.com/makeblank/blank/cfg"
+	"github.com/makeblank/blank/out"
+	"github.com/makeblank/blank/snap"
+	"github.com/makeblank/blank/tpl"
+
+	. "github.com/makeblank/blank/std"
+)
+
+// Runs the steps of a recipe blank.
+type recipeRunner struct {
+	blank *blk.Blank
+	doc   *blk.Doc
+	dir   string            // The recipe's directory.
+	vals  map[string]string // The values of variables.
+	env   []string          // The environment of commands.
+	w     *out.Writer       // The writer of files.
+}
+
+// Run the steps of recipe blank b in order, with the values
+// of "name=value" arguments, and log each step. Commands are
+// run with the same environment as other blanks. Stops at
+// the first step that fails.
+func runRecipe(
+	b *blk.Blank,
+	paths []string,
+	params map[string]string,
+	args []string,
+	w *out.Writer,
+) int {
+	recipe, err := b.ReadRecipe()
+
+	if err != nil {
+		WriteError(err)
+		return 1
+	}
+
+	doc, err := b.ReadDoc()
+
+	if err != nil {
+		WriteError(err)
+		return 1
+	}
+
+	env, _ := scriptArgs(blankEnv(b, paths, params), args)
+
+	r := &recipeRunner{
+		blank: b,
+		doc:   doc,
+		dir:   filepath.Dir(b.Path),
+		vals:  varValues(args),
+		env:   env,
+		w:     w,
+	}
+
+	for i, s := range recipe.Steps {
+		s = s.Expand(r.vals)
+
+		fmt.Fprintf(os.Stderr, "%s: [%d/%d] %s\n", b.Name, i+1, len(recipe.Steps), s)
+
+		if code, err := r.run(s); err != nil || code != 0 {
+			if err == nil {
+				err = fmt.Errorf("exit status %d", code)
+			}
+
+			WriteError(fmt.Errorf("%s: step %d (%s) failed: %w", b.Name, i+1, s, err))
+
+			if code == 0 {
+				code = 1
+			}
+
+			return code
+		}
+	}
+
+	return 0
+}
+
+// Run a step. Returns the exit code of the step's command, if
+// it runs one.
+func (r *recipeRunner) run(s *blk.RecipeStep) (int, error) {
+	dst, err := s.Dest()
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch s.Kind() {
+	case blk.StepCopy:
+		return 0, r.copy(s.Copy.From, dst, false)
+	case blk.StepRender:
+		return 0, r.copy(s.Render.From, dst, true)
+	case blk.StepUpdate:
+		return 0, r.update(s.Update)
+	case blk.StepDelete:
+		return 0, os.RemoveAll(dst)
+	case blk.StepMkdir:
+		return 0, os.MkdirAll(dst, 0755)
+	case blk.StepRun:
+		return RunCmd(r.command("sh", "-c", s.Run)), nil
+	case blk.StepInclude:
+		return RunCmd(r.include(s.Include)), nil
+	}
+
+	return 0, fmt.Errorf("unknown step")
+}
+
+// Copy or render a file or directory. A file is copied into
+// the destination if it is a directory.
+func (r *recipeRunner) copy(from, dst string, render bool) error {
+	src := filepath.Join(r.dir, from)
+	info, err := os.Stat(src)
+
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() && render {
+		_, err = tpl.RenderDir(src, dst, r.doc.Data(r.vals), r.doc.Verbatim, r.w)
+		return err
+	} else if info.IsDir() {
+		return copyDir(src, dst, r.w)
+	}
+
+	if d, err := os.Stat(dst); err == nil && d.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+
+	content, err := ioutil.ReadFile(src)
+
+	if err != nil {
+		return err
+	}
+
+	if render {
+		s, err := tpl.Render(from, string(content), r.doc.Data(r.vals))
+
+		if err != nil {
+			return err
+		}
+
+		content = []byte(s)
+	}
+
+	return r.w.WriteFile(dst, content, info.Mode().Perm())
+}
+
+// Merge data into a config file, which must exist.
+func (r *recipeRunner) update(a *blk.UpdateAction) error {
+	var (
+		b    bytes.Buffer
+		rule = &cfg.Rule{Mode: a.Mode, Key: a.Key}
+		t    = strings.TrimPrefix(filepath.Ext(a.File), ".")
+	)
+
+	if t == "yml" {
+		t = "yaml"
+	}
+
+	if marshallers[t] == nil {
+		return fmt.Errorf("unknown config file type: %s", a.File)
+	}
+
+	if rule.Mode == "" {
+		rule.Mode = cfg.ModeMerge
+	} else if !cfg.IsMode(rule.Mode) {
+		return fmt.Errorf("unknown merge mode: %q", rule.Mode)
+	}
+
+	path, data, err := sourceData(a.Path, a.Data)
+
+	if err != nil {
+		return err
+	}
+
+	src := &cfg.Source{
+		File: &cfg.File{Path: r.blank.Path, Data: data},
+		Rule: rule,
+		Path: path,
+	}
+
+	if a.Strategy != "" {
+		if src.Strategy, err = cfg.ReadStrategyFile(filepath.Join(r.dir, a.Strategy)); err != nil {
+			return err
+		}
+	}
+
+	info, err := os.Stat(a.File)
+
+	if err != nil {
+		return err
+	}
+
+	if err = updateFile(&b, a.File, t, t, []*cfg.Source{src}, false); err != nil {
+		return err
+	}
+
+	return r.w.WriteFile(a.File, b.Bytes(), info.Mode().Perm())
+}
+
+// Returns the command that runs another blank as a nested run
+// of this one.
+func (r *recipeRunner) include(a *blk.IncludeAction) *exec.Cmd {
+	args := []string{a.Target}
+
+	for _, n := range sortedKeys(a.Params) {
+		args = append(args, n+"="+a.Params[n])
+	}
+
+	return r.command(os.Getenv(vBLANK), args...)
+}
+
+func (r *recipeRunner) command(name string, args ...string) *exec.Cmd {
+	cmd := exec.Command(name, args...)
+	cmd.Env = r.env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd
+}
+
+// Copy the file tree in src into dst with writer w. Files and
+// directories that match a pattern in snap.Skip are skipped.
+func copyDir(src, dst string, w *out.Writer) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+
+		if err != nil {
+			return err
+		}
+
+		if snap.Ignored(filepath.ToSlash(rel), info.Name(), snap.Skip) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+
+		content, err := ioutil.ReadFile(p)
+
+		if err != nil {
+			return err
+		}
+
+		return w.WriteFile(target, content, info.Mode().Perm())
+	})
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/makeblank/blank/blk"
+	"github.com/makeblank/blank/out"
+	"gotest.tools/v3/assert"
+)
+
+func TestRecipeUpdateStrategy(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		config = filepath.Join(dir, "config.json")
+	)
+
+	r := &recipeRunner{
+		blank: &blk.Blank{Path: filepath.Join(dir, "app.blank.yaml")},
+		dir:   dir,
+		w:     out.New(out.PolicyOverwrite),
+	}
+
+	tests := []struct {
+		strategy string
+		res      string
+	}{
+		{"", `{"tags":["b"]}`},
+		{"rules.yaml", `{"tags":["a","b"]}`},
+	}
+
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, "rules.yaml"), []byte("/tags: append\n"), 0644))
+
+	for _, test := range tests {
+		assert.NilError(t, ioutil.WriteFile(config, []byte(`{"tags":["a"]}`), 0644))
+
+		err := r.update(&blk.UpdateAction{
+			File:     config,
+			Strategy: test.strategy,
+			Data:     map[string]interface{}{"tags": []interface{}{"b"}},
+		})
+
+		assert.NilError(t, err, test.strategy)
+
+		var got, want interface{}
+
+		content, err := ioutil.ReadFile(config)
+
+		assert.NilError(t, err)
+		assert.NilError(t, json.Unmarshal(content, &got))
+		assert.NilError(t, json.Unmarshal([]byte(test.res), &want))
+		assert.DeepEqual(t, got, want)
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/imdario/mergo"
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/cfg"
+	"github.com/makeblank/blank/out"
+	"github.com/makeblank/blank/tpl"
+
+	. "github.com/makeblank/blank/std"
+)
+
+const RenderCommandName = "render"
+
+const renderCommandHelp = `
+Template is rendered as a Go template and written to stdout,
+or to the output file. Options may be given before or after
+template.
+
+The data given to the template is merged from data files, in
+the order they are given, and then values are set, in order.
+A data file may be of any config file type (json, yaml.) A
+value sets the member at key, which may be a path (e.g.
+"app/name"), to a string.
+
+Helper functions are: lower, upper, title, camel, pascal,
+snake, kebab, trim, replace, split, join and default.
+
+An existing output file is not overwritten, unless a
+conflict policy p is given: skip (keep the file), overwrite,
+prompt, or new (write the output next to the file, with a
+".blank-new" extension.) The "--force" option is the same as
+"--conflict overwrite", unless a policy is given.
+
+Examples:
+  blank render README.md.tmpl -d app.yaml -o README.md
+  blank render main.go.tmpl -D name=billing -D app/port=8080
+`
+
+// The "render" subcommand type.
+type RenderCommand struct {
+	info  *Info
+	flags []*Flag
+}
+
+func (c *RenderCommand) Name() string {
+	return RenderCommandName
+}
+
+func (c *RenderCommand) Info() *Info {
+	return c.info
+}
+
+func (c *RenderCommand) Help() string {
+	return renderCommandHelp
+}
+
+func (c *RenderCommand) Flags() []*Flag {
+	return c.flags
+}
+
+// The options of the "render" subcommand.
+type renderOptions struct {
+	DataFiles []string `flag:"d"`
+	Values    []string `flag:"D"`
+	Output    string   `flag:"o"`
+	Force     bool     `flag:"force"`
+	Conflict  string   `flag:"conflict"`
+}
+
+func (c *RenderCommand) Run(args []string) error {
+	var (
+		o    renderOptions
+		data = &cfg.File{Data: map[string]interface{}{}}
+	)
+
+	args, err := arg.ParseFlagsAndArgs(c.flags, args, &o)
+
+	if err != nil {
+		return err
+	} else if len(args) == 0 {
+		return arg.ArgRequiredError("template")
+	} else if len(args) > 1 {
+		return arg.ArgError("is unexpected", args[1])
+	}
+
+	for _, p := range o.DataFiles {
+		if err = mergeDataFile(data, p); err != nil {
+			return err
+		}
+	}
+
+	for _, kv := range o.Values {
+		if err = mergeDataValue(data, kv); err != nil {
+			return err
+		}
+	}
+
+	policy := o.Conflict
+
+	if o.Force && Empty(policy) {
+		policy = out.PolicyOverwrite
+	}
+
+	return renderFile(args[0], o.Output, data.Data, policy)
+}
+
+// The default "render" subcommand instance.
+var Render = &RenderCommand{
+	info: &Info{
+		Line: "%s template [options]",
+		Desc: "Render a template file with data.",
+	},
+
+	flags: []*Flag{
+		{
+			Name:   "-d",
+			Desc:   "merge data from config `file`",
+			Type:   arg.FlagString,
+			Repeat: true,
+		},
+		{
+			Name:   "-D",
+			Desc:   "set data value `k=v`",
+			Type:   arg.FlagString,
+			Repeat: true,
+		},
+		{Name: "-o", Desc: "write to output `file`", Type: arg.FlagString},
+		{Name: "-f, --force", Desc: "overwrite an existing output file"},
+		{
+			Name: "--conflict",
+			Desc: "apply policy `p` to an existing file",
+			Type: arg.FlagString,
+			Enum: out.Policies,
+		},
+	},
+}
+
+func mergeDataFile(data *cfg.File, p string) error {
+	src, err := cfg.ReadSourceFile(p, mergo.WithOverride)
+
+	if err != nil {
+		return err
+	}
+
+	return data.MergeSource(src)
+}
+
+func mergeDataValue(data *cfg.File, kv string) error {
+	k, v, ok := splitVar(kv)
+
+	if !ok {
+		return arg.ArgError(`must be "key=value"`, kv)
+	}
+
+	return data.MergeMap(cfg.PointerToMap(k, v), mergo.WithOverride)
+}
+
+// Render the template file p with data, and write it to the
+// output file, or to stdout if output is empty. An existing
+// output file is an error, unless a conflict policy is given.
+func renderFile(p, output string, data map[string]interface{}, policy string) error {
+	if _, err := os.Stat(output); arg.Ok(output) && err == nil && Empty(policy) {
+		return fmt.Errorf("Output file exists: %s (use --force to overwrite)", output)
+	}
+
+	b, err := ioutil.ReadFile(p)
+
+	if err != nil {
+		return err
+	}
+
+	s, err := tpl.Render(filepath.Base(p), string(b), data)
+
+	if err != nil {
+		return err
+	}
+
+	if Empty(output) {
+		_, err = os.Stdout.WriteString(s)
+		return err
+	}
+
+	w := out.New(policy)
+
+	if policy == out.PolicyPrompt && IsTerminal(os.Stdin) {
+		w.In = bufio.NewReader(os.Stdin)
+		w.Out = os.Stderr
+	}
+
+	if err = w.WriteFile(output, []byte(s), 0644); err == nil {
+		w.WriteSummary(os.Stderr)
+	}
+
+	return err
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/makeblank/blank/out"
+	"github.com/makeblank/blank/proj"
+	"github.com/makeblank/blank/snap"
+)
+
+// The report of a run of a target, as written by the "--json"
+// make option.
+type runReport struct {
+	Target string        `json:"target"`
+	Status int           `json:"status"` // The exit status of the run.
+	Files  []*reportFile `json:"files"`
+}
+
+// A file touched by a run.
+type reportFile struct {
+	Path string `json:"path"`
+
+	// One of snap.Created, Modified or Deleted, or out.Skipped
+	// or Conflict for files that a blank did not write.
+	Status string `json:"status"`
+}
+
+// Returns the report of a run of target that exited with the
+// given status, made the given changes, and wrote files with
+// w. Copies of conflicting files are left out, since their
+// file is reported, and so are files in proj.Dir.
+func newRunReport(target string, status int, changes []*snap.Change, w *out.Writer) *runReport {
+	r := &runReport{
+		Target: target,
+		Status: status,
+		Files:  []*reportFile{},
+	}
+	conflicts := make(map[string]bool)
+
+	for _, f := range w.Filter(out.Skipped, out.Conflict) {
+		r.Files = append(r.Files, &reportFile{f.Path, f.Status})
+
+		if f.Status == out.Conflict {
+			conflicts[f.Path+out.NewExt] = true
+		}
+	}
+
+	for _, c := range changes {
+		if !conflicts[c.Path] && !strings.HasPrefix(c.Path, proj.Dir+"/") {
+			r.Files = append(r.Files, &reportFile{c.Path, c.Kind})
+		}
+	}
+
+	sort.SliceStable(r.Files, func(i, j int) bool {
+		return r.Files[i].Path < r.Files[j].Path
+	})
+
+	return r
+}
+
+// Write the files of the report, if there are any, in the
+// same format as out.Writer's summary.
+func (r *runReport) Write(o io.Writer) {
+	files := make([]*out.File, len(r.Files))
+
+	for i, f := range r.Files {
+		files[i] = &out.File{Path: f.Path, Status: f.Status}
+	}
+
+	out.WriteFiles(o, files)
+}
+
+// Write the report as JSON, on a single line.
+func (r *runReport) WriteJSON(o io.Writer) error {
+	return json.NewEncoder(o).Encode(r)
+}
+
+// Write report r as JSON to the file at path p, or to stderr
+// if p is "-".
+func writeJSONReport(r *runReport, p string) error {
+	if p == "-" {
+		return r.WriteJSON(os.Stderr)
+	}
+
+	f, err := os.Create(p)
+
+	if err != nil {
+		return err
+	}
+
+	if err = r.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
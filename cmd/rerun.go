@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/proj"
+)
+
+const RerunCommandName = "rerun"
+
+const rerunCommandHelpFmt = `
+Runs the given targets, or all targets recorded in the
+project's answers file (%s), again in the order
+they were first run, with the same variables as their last
+run. Targets are searched for in BLANK_PATH again, so their
+current files are used.
+`
+
+// The "rerun" subcommand type.
+type RerunCommand struct {
+	info  *Info
+	flags []*Flag
+}
+
+func (c *RerunCommand) Name() string {
+	return RerunCommandName
+}
+
+func (c *RerunCommand) Info() *Info {
+	return c.info
+}
+
+func (c *RerunCommand) Help() string {
+	return fmt.Sprintf(
+		rerunCommandHelpFmt,
+		filepath.Join(proj.Dir, proj.AnswersFile),
+	)
+}
+
+func (c *RerunCommand) Flags() []*Flag {
+	return c.flags
+}
+
+func (c *RerunCommand) Run(args []string) error {
+	opts := &makeOptions{}
+	targets, err := arg.ParseFlags(c.flags, args, opts)
+
+	if err != nil {
+		return err
+	}
+
+	answers, err := proj.ReadAnswers(".")
+
+	if err != nil {
+		return err
+	}
+
+	runs, err := findRuns(answers, targets)
+
+	if err != nil {
+		return err
+	}
+
+	for _, r := range runs {
+		if err = runTarget(opts, r.Target, runVars(r), nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the recorded runs of the given targets, or all
+// recorded runs if there are no targets.
+func findRuns(answers *proj.Answers, targets []string) ([]*proj.Run, error) {
+	runs := answers.Runs
+
+	if len(targets) > 0 {
+		runs = make([]*proj.Run, len(targets))
+
+		for i, t := range targets {
+			if runs[i] = answers.Find(t); runs[i] == nil {
+				return nil, fmt.Errorf("No recorded run of target: %s", t)
+			}
+		}
+	}
+
+	if len(runs) == 0 {
+		return nil, fmt.Errorf("No recorded runs")
+	}
+
+	return runs, nil
+}
+
+// Returns the variables of run r, as "name=value" arguments.
+func runVars(r *proj.Run) []string {
+	vars := make([]string, 0, len(r.Params))
+
+	for _, n := range sortedKeys(r.Params) {
+		vars = append(vars, n+"="+r.Params[n])
+	}
+
+	return vars
+}
+
+// The default "rerun" subcommand instance.
+var Rerun = &RerunCommand{
+	info: &Info{
+		Line: "%s [options] [target]...",
+		Desc: "Run recorded targets again.",
+	},
+
+	flags: []*Flag{
+		{Name: "--no-input", Desc: "never prompt for parameters"},
+		{Name: "--trace", Desc: "show tree of nested runs"},
+	},
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/blk"
+	"github.com/makeblank/blank/proj"
+
+	. "github.com/makeblank/blank/std"
+)
+
+const StatusCommandName = "status"
+
+const statusCommandHelpFmt = `
+Compares the runs recorded in the project's answers file
+(%s) with the blanks found in %s now,
+and lists the blanks that changed since they were applied.
+The status of a blank is one of:
+
+  changed        its content is not the same as when it
+                 was applied
+  moved          its content is the same, but it is found
+                 in another file
+  missing        it is not found anymore
+  unknown        its content was not recorded
+  current        it did not change
+
+Blanks that did not change are only listed with the "--all"
+option. Run "blank rerun [target]" to apply a changed blank
+again.
+`
+
+// Statuses of applied blanks.
+const (
+	statusChanged = "changed"
+	statusMoved   = "moved"
+	statusMissing = "missing"
+	statusUnknown = "unknown"
+	statusCurrent = "current"
+)
+
+// The "status" subcommand type.
+type StatusCommand struct {
+	info  *Info
+	flags []*Flag
+}
+
+func (c *StatusCommand) Name() string {
+	return StatusCommandName
+}
+
+func (c *StatusCommand) Info() *Info {
+	return c.info
+}
+
+func (c *StatusCommand) Help() string {
+	return fmt.Sprintf(
+		statusCommandHelpFmt,
+		filepath.Join(proj.Dir, proj.AnswersFile),
+		vBLANK_PATH,
+	)
+}
+
+func (c *StatusCommand) Flags() []*Flag {
+	return c.flags
+}
+
+func (c *StatusCommand) Run(args []string) error {
+	var o struct {
+		All bool `flag:"all"`
+	}
+
+	args, err := arg.ParseFlags(c.flags, args, &o)
+
+	if err != nil {
+		return err
+	} else if len(args) > 0 {
+		return arg.ArgError("is unexpected", args[0])
+	}
+
+	answers, err := proj.ReadAnswers(".")
+
+	if err != nil {
+		return err
+	} else if len(answers.Runs) == 0 {
+		return fmt.Errorf("No recorded runs")
+	}
+
+	paths := targetPaths()
+	n := 0
+
+	for _, r := range answers.Runs {
+		status, b, err := runStatus(r, paths)
+
+		if err != nil {
+			return err
+		}
+
+		if status == statusCurrent && !o.All {
+			continue
+		}
+
+		source := r.Source
+
+		if b != nil {
+			source = b.Path
+		}
+
+		fmt.Printf("  %-13s  %-16s  %s\n", status, r.Target, source)
+		n++
+	}
+
+	if n == 0 {
+		fmt.Println("All applied blanks are current.")
+	}
+
+	return nil
+}
+
+// Returns the status of the blank applied by run r, and the
+// blank that is found now in the given paths, if any.
+func runStatus(r *proj.Run, paths []string) (string, *blk.Blank, error) {
+	b, _ := blk.Find(r.Target, paths)
+
+	if b == nil {
+		return statusMissing, nil, nil
+	} else if Empty(r.Hash) {
+		return statusUnknown, b, nil
+	}
+
+	hash, err := b.Hash()
+
+	switch {
+	case err != nil:
+		return "", b, err
+	case hash != r.Hash:
+		return statusChanged, b, nil
+	case b.Path != r.Source:
+		return statusMoved, b, nil
+	}
+
+	return statusCurrent, b, nil
+}
+
+// The default "status" subcommand instance.
+var Status = &StatusCommand{
+	info: &Info{
+		Line: "%s [options]",
+		Desc: "List blanks that changed since they were applied.",
+	},
+
+	flags: []*Flag{
+		{Name: "-a, --all", Desc: "also list blanks that did not change"},
+	},
+}
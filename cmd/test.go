@@ -0,0 +1,288 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/blk"
+	"github.com/makeblank/blank/proj"
+	"github.com/makeblank/blank/snap"
+
+	. "github.com/makeblank/blank/std"
+)
+
+const TestCommandName = "test"
+
+const testCommandHelpFmt = `
+Runs each target in a new, empty directory, once for every
+case of its test spec, and compares the files it created
+with the case's golden tree. Without targets, all blanks in
+%[1]s that have a test spec are tested.
+
+The test spec of a target is a file "[target]%[2]s" next
+to the target's file, which lists named cases and the
+values of their variables, e.g.:
+
+  cases:
+    - name: default
+    - name: no-ci
+      params:
+        ci: false
+
+A target without a test spec has a single case, named
+%[3]q, without variables. The golden tree of a case is
+the directory "[target]%[4]s/[case]" next to the test spec.
+Targets are run with the "--no-input" make option, and the
+project's %[5]s directory is not compared.
+
+Files are compared by their contents and whether they are
+executable, as git tracks them. If the files differ, or if
+the run fails, the case fails and its changes from the
+golden tree, or the output of the run, are shown. The
+"--update" option writes the files of each successful run as
+the case's golden tree instead. The golden tree of a run
+that creates no files holds an empty %[6]q file, so that
+git keeps it.
+`
+
+// The "test" subcommand type.
+type TestCommand struct {
+	info  *Info
+	flags []*Flag
+}
+
+func (c *TestCommand) Name() string {
+	return TestCommandName
+}
+
+func (c *TestCommand) Info() *Info {
+	return c.info
+}
+
+func (c *TestCommand) Help() string {
+	return fmt.Sprintf(
+		testCommandHelpFmt,
+		vBLANK_PATH,
+		blk.TestSpecExt,
+		blk.DefaultTestCase,
+		blk.GoldenExt,
+		proj.Dir,
+		blk.GoldenKeepFile,
+	)
+}
+
+func (c *TestCommand) Flags() []*Flag {
+	return c.flags
+}
+
+func (c *TestCommand) Run(args []string) error {
+	var o struct {
+		Update bool `flag:"update"`
+	}
+
+	targets, err := arg.ParseFlags(c.flags, args, &o)
+
+	if err != nil {
+		return err
+	}
+
+	blanks, err := testBlanks(targets)
+
+	if err != nil {
+		return err
+	}
+
+	var total, failed int
+
+	for _, b := range blanks {
+		spec, err := b.ReadTestSpec()
+
+		if err != nil {
+			return err
+		} else if spec == nil {
+			spec = &blk.TestSpec{Cases: []*blk.TestCase{{Name: blk.DefaultTestCase}}}
+		}
+
+		for _, c := range spec.Cases {
+			ok, err := runTestCase(os.Stdout, b, c, o.Update)
+
+			if err != nil {
+				return err
+			}
+
+			total++
+
+			if !ok {
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n%d of %d cases failed.\n", failed, total)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n%d of %d cases passed.\n", total, total)
+
+	return nil
+}
+
+// Returns the blanks of the given targets, or all blanks that
+// have a test spec if there are no targets.
+func testBlanks(targets []string) ([]*blk.Blank, error) {
+	var (
+		paths  = targetPaths()
+		blanks []*blk.Blank
+	)
+
+	for _, t := range targets {
+		b, cands := blk.Find(t, paths)
+
+		if b == nil {
+			return nil, targetNotFoundError(t, cands)
+		}
+
+		blanks = append(blanks, b)
+	}
+
+	if len(targets) > 0 {
+		return blanks, nil
+	}
+
+	list, err := blk.List(paths)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range list {
+		if _, err := os.Stat(b.TestSpecPath()); err == nil && !b.Hidden {
+			blanks = append(blanks, b)
+		}
+	}
+
+	if len(blanks) == 0 {
+		return nil, fmt.Errorf("No blanks with a test spec in %s", vBLANK_PATH)
+	}
+
+	return blanks, nil
+}
+
+// Run blank b with the variables of test case c in a new
+// directory, and compare its files with the case's golden
+// tree, or write them as the golden tree if update is true.
+// Writes the result to w. Returns false if the case failed.
+func runTestCase(w io.Writer, b *blk.Blank, c *blk.TestCase, update bool) (bool, error) {
+	tmp, err := ioutil.TempDir("", "blank-test-")
+
+	if err != nil {
+		return false, err
+	}
+
+	defer os.RemoveAll(tmp)
+
+	var (
+		name   = b.Name + "/" + c.Name
+		args   = []string{MakeCommandName, "--no-input", b.Name}
+		output bytes.Buffer
+	)
+
+	for _, n := range sortedKeys(c.Params) {
+		args = append(args, n+"="+c.Params[n])
+	}
+
+	sep := string(filepath.ListSeparator)
+	cmd := exec.Command(os.Getenv(vBLANK), args...)
+	cmd.Dir = tmp
+	cmd.Env = append(os.Environ(), vBLANK_PATH+"="+strings.Join(targetPaths(), sep))
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if code := RunCmd(cmd); code != 0 {
+		fmt.Fprintf(w, "FAIL    %s (exit status %d)\n", name, code)
+		writeIndented(w, output.String())
+		return false, nil
+	}
+
+	got, err := snap.Take(tmp, proj.Dir)
+
+	if err != nil {
+		return false, err
+	}
+
+	golden := b.GoldenPath(c)
+
+	if update {
+		if err = writeGolden(tmp, golden, len(got) == 0); err != nil {
+			return false, err
+		}
+
+		fmt.Fprintf(w, "updated %s\n", name)
+		return true, nil
+	}
+
+	want, err := snap.Take(golden, "/"+blk.GoldenKeepFile)
+
+	if os.IsNotExist(err) {
+		fmt.Fprintf(w, "FAIL    %s (no golden tree, use --update to write it)\n", name)
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	changes := snap.CompareContent(want, got)
+
+	if len(changes) == 0 {
+		fmt.Fprintf(w, "ok      %s\n", name)
+		return true, nil
+	}
+
+	fmt.Fprintf(w, "FAIL    %s\n", name)
+	writeChanges(w, golden, tmp, changes)
+
+	return false, nil
+}
+
+// Replace the golden tree in directory golden with the files
+// in directory src. The golden tree of a run that created no
+// files holds only an empty GoldenKeepFile, so that git keeps
+// its directory.
+func writeGolden(src, golden string, empty bool) error {
+	if err := os.RemoveAll(golden); err != nil {
+		return err
+	} else if err = os.MkdirAll(golden, 0755); err != nil {
+		return err
+	} else if empty {
+		return ioutil.WriteFile(filepath.Join(golden, blk.GoldenKeepFile), nil, 0644)
+	}
+
+	return snap.Copy(src, golden, proj.Dir)
+}
+
+// Write s with every line indented.
+func writeIndented(w io.Writer, s string) {
+	for _, line := range strings.SplitAfter(strings.TrimRight(s, "\n"), "\n") {
+		fmt.Fprintf(w, "  %s", line)
+	}
+	fmt.Fprintln(w)
+}
+
+// The default "test" subcommand instance.
+var Test = &TestCommand{
+	info: &Info{
+		Line: "%s [options] [target]...",
+		Desc: "Test targets against golden trees.",
+	},
+
+	flags: []*Flag{
+		{Name: "--update", Desc: "write golden trees from the runs"},
+	},
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/makeblank/blank/blk"
+	"gotest.tools/v3/assert"
+)
+
+// Set by tests to run the test binary as the blank program, as
+// runs of blanks do.
+const vBLANK_TEST_MAIN = "BLANK_TEST_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(vBLANK_TEST_MAIN) != "" {
+		Main(os.Args[1:])
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestRunTestCase(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(vBLANK_TEST_MAIN, "1")
+	t.Setenv(vBLANK_CHAIN, "")
+
+	defer func(paths []string) { blankPaths = paths }(blankPaths)
+	blankPaths = []string{dir}
+
+	blanks := map[string]string{
+		"hello": "echo \"hello $name\" > hello.txt\n",
+		"empty": "true\n",
+	}
+
+	for name, script := range blanks {
+		p := filepath.Join(dir, name+".sh")
+		assert.NilError(t, ioutil.WriteFile(p, []byte(script), 0644))
+	}
+
+	c := &blk.TestCase{Name: blk.DefaultTestCase, Params: map[string]string{"name": "x"}}
+
+	for name := range blanks {
+		b, _ := blk.Find(name, targetPaths())
+		assert.Assert(t, b != nil, name)
+
+		run := func(update bool) (bool, string) {
+			var w bytes.Buffer
+
+			ok, err := runTestCase(&w, b, c, update)
+			assert.NilError(t, err, name)
+
+			return ok, w.String()
+		}
+
+		ok, res := run(false)
+		assert.Assert(t, !ok, name)
+		assert.Equal(t, res, "FAIL    "+name+"/default (no golden tree, use --update to write it)\n")
+
+		ok, res = run(true)
+		assert.Assert(t, ok, name)
+		assert.Equal(t, res, "updated "+name+"/default\n")
+
+		ok, res = run(false)
+		assert.Assert(t, ok, name)
+		assert.Equal(t, res, "ok      "+name+"/default\n")
+	}
+
+	// an empty golden tree has a file so that git keeps it
+	keep := filepath.Join(dir, "empty"+blk.GoldenExt, blk.DefaultTestCase, blk.GoldenKeepFile)
+	_, err := os.Stat(keep)
+	assert.NilError(t, err)
+
+	b, _ := blk.Find("hello", targetPaths())
+	golden := filepath.Join(dir, "hello"+blk.GoldenExt, blk.DefaultTestCase, "hello.txt")
+
+	// permissions other than the executable bit do not matter
+	assert.NilError(t, os.Chmod(golden, 0600))
+	ok, err := runTestCase(ioutil.Discard, b, c, false)
+
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+
+	assert.NilError(t, os.Chmod(golden, 0700))
+	ok, err = runTestCase(ioutil.Discard, b, c, false)
+
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+
+	assert.NilError(t, ioutil.WriteFile(golden, []byte("hello y\n"), 0644))
+	ok, err = runTestCase(ioutil.Discard, b, c, false)
+
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+}
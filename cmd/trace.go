@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The maximum depth of nested runs, if BLANK_MAX_DEPTH is not
+// set.
+const defaultMaxDepth = 16
+
+var chainSep = string(filepath.ListSeparator)
+
+// Returns the chain of targets of the blank runs that this
+// program is nested in, outermost first.
+func currentChain() []string {
+	if c := os.Getenv(vBLANK_CHAIN); c != "" {
+		return strings.Split(c, chainSep)
+	}
+	return nil
+}
+
+// Returns the chain of targets of the blank runs that a run
+// of target is nested in, including target itself.
+func chain(target string) string {
+	return strings.Join(append(currentChain(), target), chainSep)
+}
+
+// Check that running target does not nest runs in a cycle or
+// deeper than the maximum depth.
+func checkChain(target string) error {
+	var (
+		c   = append(currentChain(), target)
+		max = defaultMaxDepth
+	)
+
+	if v := os.Getenv(vBLANK_MAX_DEPTH); v != "" {
+		n, err := strconv.Atoi(v)
+
+		if err != nil || n < 1 {
+			return fmt.Errorf("%s must be a positive integer: %q", vBLANK_MAX_DEPTH, v)
+		}
+
+		max = n
+	}
+
+	for _, t := range c[:len(c)-1] {
+		if t == target {
+			return fmt.Errorf("Recursive run: %s", strings.Join(c, " -> "))
+		}
+	}
+
+	if len(c) > max {
+		return fmt.Errorf(
+			"Maximum depth of %d runs reached: %s",
+			max,
+			strings.Join(c, " -> "),
+		)
+	}
+
+	return nil
+}
+
+// Start tracing runs, if on is true and this program is not
+// nested in a traced run already, by creating the file that
+// all nested runs add to. Returns a function that shows the
+// tree of runs and removes the file.
+func startTrace(on bool) (stop func()) {
+	stop = func() {}
+
+	if !on || os.Getenv(vBLANK_TRACE) != "" {
+		return
+	}
+
+	f, err := ioutil.TempFile("", "blank-trace-")
+
+	if err != nil {
+		return
+	}
+
+	f.Close()
+	os.Setenv(vBLANK_TRACE, f.Name())
+
+	return func() {
+		os.Unsetenv(vBLANK_TRACE)
+		writeTrace(os.Stderr, f.Name())
+		os.Remove(f.Name())
+	}
+}
+
+// Add a run of target to the trace file, if runs are traced.
+func traceRun(target string, start time.Time, code int) {
+	p := os.Getenv(vBLANK_TRACE)
+
+	if p == "" {
+		return
+	}
+
+	f, err := os.OpenFile(p, os.O_APPEND|os.O_WRONLY, 0644)
+
+	if err != nil {
+		return
+	}
+
+	defer f.Close()
+
+	fmt.Fprintf(
+		f,
+		"%d\t%d\t%d\t%s\n",
+		start.UnixNano(),
+		time.Since(start),
+		code,
+		chain(target),
+	)
+}
+
+type traceRecord struct {
+	start    int64
+	duration time.Duration
+	code     int
+	chain    []string
+}
+
+// Write the tree of runs in the trace file at p to w.
+func writeTrace(w io.Writer, p string) {
+	f, err := os.Open(p)
+
+	if err != nil {
+		return
+	}
+
+	defer f.Close()
+
+	var (
+		records []*traceRecord
+		s       = bufio.NewScanner(f)
+		base    = len(currentChain())
+	)
+
+	for s.Scan() {
+		fields := strings.SplitN(s.Text(), "\t", 4)
+
+		if len(fields) != 4 {
+			continue
+		}
+
+		r := &traceRecord{chain: strings.Split(fields[3], chainSep)}
+		r.start, _ = strconv.ParseInt(fields[0], 10, 64)
+		d, _ := strconv.ParseInt(fields[1], 10, 64)
+		r.duration = time.Duration(d)
+		r.code, _ = strconv.Atoi(fields[2])
+
+		records = append(records, r)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].start < records[j].start
+	})
+
+	fmt.Fprintln(w, "\nRuns:")
+
+	for _, r := range records {
+		depth := len(r.chain) - base - 1
+		name := strings.Repeat("  ", depth) + r.chain[len(r.chain)-1]
+		line := r.duration.Round(time.Millisecond).String()
+
+		if r.code != 0 {
+			line = fmt.Sprintf("%s (failed with status %d)", line, r.code)
+		}
+
+		fmt.Fprintf(w, FlagLineFormat, name, line)
+	}
+}
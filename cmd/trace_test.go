@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCheckChain(t *testing.T) {
+	tests := []struct {
+		chain    string
+		maxDepth string
+		target   string
+		err      string
+	}{
+		{"", "", "a", ""},
+		{"a:b", "", "c", ""},
+		{"a", "", "a", "Recursive run: a -> a"},
+		{"a:b:c", "", "b", "Recursive run: a -> b -> c -> b"},
+		{"a:b", "3", "c", ""},
+		{"a:b:c", "3", "d", "Maximum depth of 3 runs reached: a -> b -> c -> d"},
+		{"", "0", "a", `BLANK_MAX_DEPTH must be a positive integer: "0"`},
+		{"", "x", "a", `BLANK_MAX_DEPTH must be a positive integer: "x"`},
+	}
+
+	for _, test := range tests {
+		t.Setenv(vBLANK_CHAIN, strings.ReplaceAll(test.chain, ":", chainSep))
+		t.Setenv(vBLANK_MAX_DEPTH, test.maxDepth)
+
+		err := checkChain(test.target)
+
+		if test.err != "" {
+			assert.Error(t, err, test.err, "%+v", test)
+		} else {
+			assert.NilError(t, err, "%+v", test)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Setenv(vBLANK_CHAIN, "")
+	assert.Equal(t, chain("a"), "a")
+
+	t.Setenv(vBLANK_CHAIN, "a"+chainSep+"b")
+	assert.Equal(t, chain("c"), strings.Join([]string{"a", "b", "c"}, chainSep))
+}
+
+func TestWriteTrace(t *testing.T) {
+	t.Setenv(vBLANK_CHAIN, "")
+
+	p := filepath.Join(t.TempDir(), "trace")
+	records := []string{
+		"3\t1000000\t0\ta" + chainSep + "c",
+		"2\t2000000\t2\ta" + chainSep + "b",
+		"1\t5000000\t0\ta",
+		"invalid",
+	}
+
+	assert.NilError(t, ioutil.WriteFile(p, []byte(strings.Join(records, "\n")), 0644))
+
+	var b bytes.Buffer
+
+	writeTrace(&b, p)
+
+	assert.Equal(t, b.String(), `
+Runs:
+  a              5ms
+    b            2ms (failed with status 2)
+    c            1ms
+`)
+}
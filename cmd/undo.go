@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/proj"
+	"github.com/makeblank/blank/snap"
+
+	. "github.com/makeblank/blank/std"
+)
+
+const UndoCommandName = "undo"
+
+// The file whose patterns the journal ignores.
+const gitignoreFile = ".gitignore"
+
+const undoCommandHelpFmt = `
+Every run of a target changes files in the working directory,
+which is the project's directory. The previous state of the
+files that it changed is recorded in the project's journal
+(%s), so that the run can be undone: files and
+empty directories it created are deleted, and files and
+directories it modified or deleted are restored. Runs nested
+in other runs are part of them.
+
+The answers that the run recorded for the target are
+replaced by the previous ones, unless the target was run
+again since. Other files in the project directory (%s),
+and files that match a pattern in the project's .gitignore
+file, are not recorded, and are left as they are.
+
+Without id, the last run is undone. It is an error if files
+the run changed were changed again since, unless the
+"--force" option is given. An undone run is removed from the
+journal.
+`
+
+// The "undo" subcommand type.
+type UndoCommand struct {
+	info  *Info
+	flags []*Flag
+}
+
+func (c *UndoCommand) Name() string {
+	return UndoCommandName
+}
+
+func (c *UndoCommand) Info() *Info {
+	return c.info
+}
+
+func (c *UndoCommand) Help() string {
+	return fmt.Sprintf(undoCommandHelpFmt, proj.JournalPath(), proj.Dir)
+}
+
+func (c *UndoCommand) Flags() []*Flag {
+	return c.flags
+}
+
+func (c *UndoCommand) Run(args []string) error {
+	var o struct {
+		List  bool `flag:"list"`
+		Force bool `flag:"force"`
+	}
+
+	args, err := arg.ParseFlagsAndArgs(c.flags, args, &o)
+
+	if err != nil {
+		return err
+	} else if len(args) > 1 {
+		return arg.ArgError("is unexpected", args[1])
+	}
+
+	runs, err := proj.ReadJournal(".")
+
+	if err != nil {
+		return err
+	} else if len(runs) == 0 {
+		return fmt.Errorf("No runs to undo")
+	}
+
+	if o.List {
+		for _, r := range runs {
+			fmt.Printf(
+				"  %-4d  %s  %-16s  %d changes\n",
+				r.ID,
+				r.Time.Format("2006-01-02 15:04:05"),
+				r.Target,
+				len(r.Changes),
+			)
+		}
+		return nil
+	}
+
+	r := runs[len(runs)-1]
+
+	if len(args) > 0 {
+		if r = findJournalRun(runs, args[0]); r == nil {
+			return fmt.Errorf("No run to undo with id: %s", args[0])
+		}
+	}
+
+	if conflicts, err := r.Conflicts("."); err != nil {
+		return err
+	} else if len(conflicts) > 0 && !o.Force {
+		paths := make([]string, len(conflicts))
+
+		for i, c := range conflicts {
+			paths[i] = c.Path
+		}
+
+		return fmt.Errorf(
+			"Files were changed since run %d of %s (use --force to undo anyway):\n  %s",
+			r.ID,
+			r.Target,
+			strings.Join(paths, "\n  "),
+		)
+	}
+
+	if err = r.Undo("."); err != nil {
+		return err
+	}
+
+	fmt.Printf("Undid run %d of %s:\n", r.ID, r.Target)
+
+	for _, c := range r.Changes {
+		fmt.Printf("  %-13s  %s\n", undoneKinds[c.Kind], c.Path)
+	}
+
+	return nil
+}
+
+// What undoing a change does to a file.
+var undoneKinds = map[string]string{
+	snap.Created:  "deleted",
+	snap.Modified: "restored",
+	snap.Deleted:  "restored",
+}
+
+func findJournalRun(runs []*proj.JournalRun, id string) *proj.JournalRun {
+	n, err := strconv.Atoi(id)
+
+	if err != nil {
+		return nil
+	}
+
+	for _, r := range runs {
+		if r.ID == n {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// The default "undo" subcommand instance.
+var Undo = &UndoCommand{
+	info: &Info{
+		Line: "%s [options] [id]",
+		Desc: "Undo a run of a target.",
+	},
+
+	flags: []*Flag{
+		{Name: "-l, --list", Desc: "list the runs that can be undone"},
+		{Name: "-f, --force", Desc: "undo even if files changed since"},
+	},
+}
+
+// Start recording the changes that a run of target makes to
+// the files and directories in the working directory, unless
+// the run is nested in another one, by taking their metadata,
+// a copy of the files and the target's run in the answers
+// file. Files that the project's .gitignore file ignores are
+// left out, and so is the project directory. Returns a
+// function that adds the run to the journal, if it changed
+// anything, removes the copy, and returns the changes to the
+// files. Its ok result is false if they were not recorded.
+func startJournal(target string) (done func() (changes []*snap.Change, ok bool)) {
+	done = func() ([]*snap.Change, bool) { return nil, false }
+
+	if os.Getenv(vBLANK_CHAIN) != "" {
+		return
+	}
+
+	skip, err := snap.ReadIgnore(gitignoreFile)
+
+	if err != nil {
+		WriteError(fmt.Errorf("Cannot record run in journal: %w", err))
+		return
+	}
+
+	tmp, err := ioutil.TempDir("", "blank-journal-")
+
+	if err != nil {
+		WriteError(err)
+		return
+	}
+
+	before, err := proj.TakeJournalState(".", target, skip)
+
+	if err == nil {
+		err = snap.Copy(".", tmp, append([]string{"/" + proj.Dir}, skip...)...)
+	}
+
+	if err != nil {
+		WriteError(fmt.Errorf("Cannot record run in journal: %w", err))
+		os.RemoveAll(tmp)
+		return
+	}
+
+	return func() ([]*snap.Change, bool) {
+		defer os.RemoveAll(tmp)
+
+		after, err := proj.TakeJournalState(".", target, skip)
+
+		if err != nil {
+			WriteError(fmt.Errorf("Cannot record run in journal: %w", err))
+			return nil, false
+		}
+
+		if _, err = proj.AddJournalRun(".", target, tmp, before, after); err != nil {
+			WriteError(fmt.Errorf("Cannot record run in journal: %w", err))
+		}
+
+		return snap.Compare(before.Files, after.Files), true
+	}
+}
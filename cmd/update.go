@@ -2,18 +2,20 @@ package cmd
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 
 	"github.com/imdario/mergo"
+	"github.com/makeblank/blank/arg"
 	"github.com/makeblank/blank/cfg"
 	"gopkg.in/yaml.v3"
 
-	. "github.com/makeblank/blank/arg"
 	. "github.com/makeblank/blank/std"
 )
 
@@ -29,22 +31,43 @@ member in target data (e.g. "/path/to/member"). If it is
 ommitted, json must be an object and the operation is
 applied to the entire target data.
 
+A path may also contain "*" segments, which match every
+member of an object or element of an array, and selector
+segments such as "[name=build]", which match objects whose
+member has that value. The operation is then applied to
+every match, and it is an error if there is none.
+
 The json argument must be a JSON string, or a path to a
 config file by prefixing it with an "@" sign.
 
+If a key field k is given, elements of object arrays with
+the same key are merged and the others are appended, so that
+arrays such as CI steps are not duplicated.
+
+The strategy s (json or "@" file) maps paths in target data to
+the rules used to merge the members at those paths, instead
+of the operation. A rule is either a mode, or an object with
+"mode" and "key" members. The modes are: set, merge, append,
+append-unique, prepend, union and override. In a blank run,
+a relative strategy file that is not in the working
+directory is read from the directory of the blank's file
+(BLANK_SOURCE), so that blanks can ship their strategies.
+
 Examples:
   blank update package.json -s /dependencies/eslint '"^7"'
   blank update .eslintrc.json -a /extends '["standard"]'
   blank update config.yaml -m @base.yaml
+  blank update -k name ci.json -m /steps @steps.json
+  blank update lerna.json -m '/packages/*/version' '"2.0.0"'
+  blank update ci.json -u '/jobs/[name=build]/steps' @steps.json
+  blank update --strategy @rules.yaml package.json -m @base.json
 `
 
 var (
 	updateExtraHelp  string
 	updateOperations []string
 
-	fileTypes    = []string{"json", "yaml"}
-	fileTypesStr = strings.Join(fileTypes, ", ")
-	fileTypesErr = fmt.Sprintf("must be: %s", fileTypesStr)
+	fileTypes = []string{"json", "yaml"}
 
 	marshallers = map[string]marshal{
 		"json": jsonMarshal,
@@ -77,33 +100,41 @@ func (c *UpdateCommand) Help() string {
 
 func (c *UpdateCommand) Run(args []string) error {
 	var (
-		target, a, t string
-		sources      []*cfg.Source
-		input        = "json"
-		output       = "json"
-		o            = 0
+		target, a string
+		sources   []*cfg.Source
+		strategy  cfg.Strategy
+		err       error
+		o         = 0
 	)
 
-	sources = make([]*cfg.Source, 0)
+	var opts struct {
+		In         string `flag:"in"`
+		Out        string `flag:"out"`
+		Key        string `flag:"key"`
+		Strategy   string `flag:"strategy"`
+		AllowEmpty bool   `flag:"allow-empty"`
+	}
 
-	for len(args) > 0 {
-		if a, args = NextFlag(args, "-io", "--in", "--out"); Empty(a) {
-			break
-		}
+	if args, err = arg.ParseFlags(c.flags, args, &opts); err != nil {
+		return err
+	}
 
-		if t, args = NextArg(args, fileTypes...); Empty(t) {
-			return FlagError(fileTypesErr, a)
+	if t := opts.Strategy; arg.Ok(t) {
+		if t[0] == '@' {
+			strategy, err = cfg.ReadStrategyFile(strategyPath(t[1:]))
+		} else {
+			strategy, err = cfg.ReadStrategyBytes([]byte(t), "--strategy", "json")
 		}
 
-		if ok, _ := IsFlag(a, "-i", "--in"); ok {
-			input = t
-		} else {
-			output = t
+		if err != nil {
+			return err
 		}
 	}
 
-	if target, args = NextArg(args); Empty(target) {
-		return ArgRequiredError("target")
+	sources = make([]*cfg.Source, 0)
+
+	if target, args = arg.NextArg(args); Empty(target) {
+		return arg.ArgRequiredError("target")
 	}
 
 	for len(args) > 0 {
@@ -115,19 +146,19 @@ func (c *UpdateCommand) Run(args []string) error {
 			is           bool
 		)
 
-		if a, args = NextFlag(args); Empty(a) {
-			return FlagRequiredError("operation")
-		} else if is, ops = IsFlag(a, updateOperations...); !is {
-			return FlagUnknownError(ops[0])
+		if a, args = arg.NextFlag(args); Empty(a) {
+			return arg.FlagRequiredError("operation")
+		} else if is, ops = arg.IsFlag(a, updateOperations...); !is {
+			return arg.FlagUnknownError(ops[0])
 		}
 
-		if a, args = NextArg(args); Empty(a) {
-			return ArgRequiredError("path", "json")
+		if a, args = arg.NextArg(args); Empty(a) {
+			return arg.ArgRequiredError("path", "json")
 		}
 
 		name := fmt.Sprintf("Op#%d", o)
 
-		if b, args = NextArg(args); Ok(b) {
+		if b, args = arg.NextArg(args); arg.Ok(b) {
 			path = a
 			src = b
 		} else {
@@ -143,7 +174,7 @@ func (c *UpdateCommand) Run(args []string) error {
 			data = []byte(src)
 		}
 
-		if src, err := newSource(name, path, ops, data); err != nil {
+		if src, err := newSource(name, path, opts.Key, strategy, ops, data); err != nil {
 			return err
 		} else {
 			sources = append(sources, src)
@@ -152,13 +183,28 @@ func (c *UpdateCommand) Run(args []string) error {
 		o++
 	}
 
-	return updateFile(os.Stdout, target, input, output, sources)
+	return updateFile(os.Stdout, target, opts.In, opts.Out, sources, opts.AllowEmpty)
 }
 
 func (c *UpdateCommand) Flags() []*Flag {
 	return c.flags
 }
 
+// Returns the path of strategy file p: p itself if it is
+// absolute or exists, or else p in the directory of the file
+// of the running blank, if there is one.
+func strategyPath(p string) string {
+	src := os.Getenv(vBLANK_SOURCE)
+
+	if filepath.IsAbs(p) || Empty(src) {
+		return p
+	} else if _, err := os.Stat(p); err == nil {
+		return p
+	}
+
+	return filepath.Join(filepath.Dir(src), p)
+}
+
 // The default "update" subcommand instance.
 var Update = &UpdateCommand{
 	info: &Info{
@@ -168,12 +214,32 @@ var Update = &UpdateCommand{
 
 	flags: []*Flag{
 		{
-			Name: "-i, --in",
-			Desc: fmt.Sprintf("read target as `t` (%s)", fileTypesStr),
+			Name:    "-i, --in",
+			Desc:    "read target as `t`",
+			Type:    arg.FlagString,
+			Enum:    fileTypes,
+			Default: "json",
+		},
+		{
+			Name:    "-o, --out",
+			Desc:    "output as `t`",
+			Type:    arg.FlagString,
+			Enum:    fileTypes,
+			Default: "json",
 		},
 		{
-			Name: "-o, --out",
-			Desc: fmt.Sprintf("output as `t` (%s)", fileTypesStr),
+			Name: "-k, --key",
+			Desc: "merge object arrays by key field `k`",
+			Type: arg.FlagString,
+		},
+		{
+			Name: "--strategy",
+			Desc: "merge members by rules in `s` (json)",
+			Type: arg.FlagString,
+		},
+		{
+			Name: "--allow-empty",
+			Desc: "allow paths that match nothing",
 		},
 	},
 
@@ -181,33 +247,39 @@ var Update = &UpdateCommand{
 		{Name: "-s", Desc: "set new values only"},
 		{Name: "-m", Desc: "merge values"},
 		{Name: "-a", Desc: `concatenate array values (implies "-m")`},
+		{Name: "-u", Desc: `append missing array values (implies "-m")`},
+		{Name: "-p", Desc: `prepend missing array values (implies "-m")`},
 	},
 }
 
 // Create new cfg.Source based on operations from cmd line.
-func newSource(n, p string, ops []string, js []byte) (s *cfg.Source, err error) {
+//
+// If key or strategy is not empty, or an operation cannot be
+// expressed with mergo options, a cfg.Rule is used instead.
+func newSource(
+	n, p, key string,
+	st cfg.Strategy,
+	ops []string,
+	js []byte,
+) (s *cfg.Source, err error) {
 	var (
-		kind reflect.Kind
+		path string
 		file *cfg.File
 		data interface{}
 		map_ map[string]interface{}
+		rule = &cfg.Rule{Mode: cfg.ModeSet, Key: key}
+		use  = key != "" || st != nil
 		opts = make([]func(*mergo.Config), 0, len(ops))
 	)
 
-	p = strings.Trim(p, "/")
-
 	if err = json.Unmarshal(js, &data); err != nil {
 		return
 	}
 
-	kind = reflect.TypeOf(data).Kind()
-
-	if p == "" && kind != reflect.Map {
-		return nil, fmt.Errorf("json must be an object if path is omitted")
-	} else if p != "" {
-		map_ = cfg.PointerToMap(p, data)
-	} else {
-		map_ = data.(map[string]interface{})
+	if path, map_, err = sourceData(p, data); err != nil {
+		return
+	} else if path != "" {
+		use = true
 	}
 
 	file = &cfg.File{
@@ -219,23 +291,75 @@ func newSource(n, p string, ops []string, js []byte) (s *cfg.Source, err error)
 		switch op {
 		case "a":
 			opts = append(opts, mergo.WithOverride, mergo.WithAppendSlice)
+			rule.Mode = cfg.ModeAppend
 		case "m":
 			opts = append(opts, mergo.WithOverride)
+			if rule.Mode == cfg.ModeSet {
+				rule.Mode = cfg.ModeMerge
+			}
+		case "u":
+			rule.Mode = cfg.ModeAppendUnique
+			use = true
+		case "p":
+			rule.Mode = cfg.ModePrepend
+			use = true
 		}
 	}
 
-	return &cfg.Source{
+	s = &cfg.Source{
 		File:    file,
 		Options: opts,
-	}, nil
+	}
+
+	if use {
+		s.Rule = rule
+		s.Strategy = st
+		s.Path = path
+	}
+
+	return s, nil
+}
+
+// Returns the data of a source that merges data at path p,
+// and the path pattern to merge the source at, if p is one.
+func sourceData(p string, data interface{}) (string, map[string]interface{}, error) {
+	var kind reflect.Kind
+
+	if p = strings.Trim(p, "/"); data != nil {
+		kind = reflect.TypeOf(data).Kind()
+	}
+
+	if p == "" && kind != reflect.Map {
+		return "", nil, fmt.Errorf("json must be an object if path is omitted")
+	} else if cfg.IsPattern(p) {
+		// merge into the parent of the last segment, unless
+		// it is a pattern itself
+		if dir, base := cfg.SplitPath(p); !cfg.IsPattern(base) {
+			return dir, map[string]interface{}{base: data}, nil
+		} else if kind == reflect.Map {
+			return p, data.(map[string]interface{}), nil
+		}
+		return "", nil, fmt.Errorf("json must be an object if path ends with a pattern")
+	} else if p != "" {
+		return "", cfg.PointerToMap(p, data), nil
+	}
+
+	return "", data.(map[string]interface{}), nil
 }
 
 // update config file from given sources and write updated
-// data as given type to the writer.
-func updateFile(w io.Writer, p, in, out string, s []*cfg.Source) (err error) {
+// data as given type to the writer. Sources whose path
+// matches nothing are an error, unless empty is true.
+func updateFile(
+	w io.Writer,
+	p, in, out string,
+	s []*cfg.Source,
+	empty bool,
+) (err error) {
 	var (
 		file *cfg.File
 		fn   marshal
+		b    []byte
 	)
 
 	if fn = marshallers[out]; fn == nil {
@@ -246,18 +370,22 @@ func updateFile(w io.Writer, p, in, out string, s []*cfg.Source) (err error) {
 		return err
 	}
 
-	if err = file.MergeSource(s...); err != nil {
-		return err
-	} else {
-		var b []byte
+	for _, src := range s {
+		err = file.MergeSource(src)
 
-		if b, err = fn(file.Data); err != nil {
-			return
-		} else {
-			_, err = w.Write(b)
+		if errors.Is(err, cfg.ErrNoMatch) && empty {
+			err = nil
+		} else if err != nil {
+			return err
 		}
 	}
 
+	if b, err = fn(file.Data); err != nil {
+		return
+	} else {
+		_, err = w.Write(b)
+	}
+
 	return
 }
 
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestStrategyPath(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Setenv(vBLANK_SOURCE, "")
+	assert.Equal(t, strategyPath("rules.yaml"), "rules.yaml")
+
+	t.Setenv(vBLANK_SOURCE, filepath.Join(dir, "web.mk"))
+	assert.Equal(t, strategyPath("rules.yaml"), filepath.Join(dir, "rules.yaml"))
+	assert.Equal(t, strategyPath("update.go"), "update.go")
+	assert.Equal(t, strategyPath("/rules.yaml"), "/rules.yaml")
+}
@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/out"
+	"github.com/makeblank/blank/proj"
+
+	. "github.com/makeblank/blank/std"
+)
+
+const UpgradeCommandName = "upgrade"
+
+const upgradeCommandHelpFmt = `
+Runs the given targets, or all targets recorded in the
+project's answers file (%s), again if their blank
+changed since it was applied (see "blank status"), with the
+same variables as their last run.
+
+Each target is first run in a copy of the working directory
+and the changes it would make are shown, as with the
+"--preview" make option. The user is then asked whether to
+apply them, unless the "--yes" option is given. Without it,
+stdin must be a terminal. Applied targets are recorded in
+the answers file again, so that they are current.
+
+Parameters are never prompted for. The conflict policy of
+the blanks applies to files they write, unless the
+"--conflict" option is given.
+`
+
+// The "upgrade" subcommand type.
+type UpgradeCommand struct {
+	info  *Info
+	flags []*Flag
+}
+
+func (c *UpgradeCommand) Name() string {
+	return UpgradeCommandName
+}
+
+func (c *UpgradeCommand) Info() *Info {
+	return c.info
+}
+
+func (c *UpgradeCommand) Help() string {
+	return fmt.Sprintf(
+		upgradeCommandHelpFmt,
+		filepath.Join(proj.Dir, proj.AnswersFile),
+	)
+}
+
+func (c *UpgradeCommand) Flags() []*Flag {
+	return c.flags
+}
+
+func (c *UpgradeCommand) Run(args []string) error {
+	var o struct {
+		Yes      bool   `flag:"yes"`
+		Conflict string `flag:"conflict"`
+	}
+
+	targets, err := arg.ParseFlags(c.flags, args, &o)
+
+	if err != nil {
+		return err
+	}
+
+	var (
+		// parameters are never prompted for, but changes are
+		canConfirm = canPrompt(&makeOptions{})
+		opts       = &makeOptions{NoInput: true, Conflict: o.Conflict}
+	)
+
+	answers, err := proj.ReadAnswers(".")
+
+	if err != nil {
+		return err
+	}
+
+	runs, err := findRuns(answers, targets)
+
+	if err != nil {
+		return err
+	}
+
+	var (
+		paths = targetPaths()
+		in    = bufio.NewReader(os.Stdin)
+		n     int
+	)
+
+	for _, r := range runs {
+		status, b, err := runStatus(r, paths)
+
+		if err != nil {
+			return err
+		}
+
+		switch status {
+		case statusCurrent:
+			continue
+		case statusMissing:
+			WriteError(fmt.Errorf("Cannot upgrade %s: it is not found in %s", r.Target, vBLANK_PATH))
+			continue
+		}
+
+		n++
+
+		margs := append(append(opts.args(), r.Target), runVars(r)...)
+
+		fmt.Printf("Upgrading %s (%s):\n", r.Target, status)
+
+		if ok, err := previewUpgrade(margs); err != nil {
+			return err
+		} else if !ok {
+			if err = recordRun(b, runVars(r)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !o.Yes {
+			if !canConfirm {
+				return fmt.Errorf("Cannot ask to confirm the upgrade of %s (use --yes to apply it)", r.Target)
+			} else if ok, err := promptConfirm(in, os.Stderr, "Apply these changes?"); err != nil {
+				return err
+			} else if !ok {
+				fmt.Printf("Skipped %s.\n", r.Target)
+				continue
+			}
+		}
+
+		if err = runTarget(opts, r.Target, runVars(r), nil); err != nil {
+			return err
+		}
+	}
+
+	if n == 0 {
+		fmt.Println("All applied blanks are current.")
+	}
+
+	return nil
+}
+
+// Run the make command with the given arguments in a copy of
+// the working directory, and show the changes it made to the
+// project's files. Returns false if there were none.
+//
+// NOTE: if the run fails, this program exits with its status.
+func previewUpgrade(args []string) (bool, error) {
+	tmp, err := ioutil.TempDir("", "blank-upgrade-")
+
+	if err != nil {
+		return false, err
+	}
+
+	defer os.RemoveAll(tmp)
+
+	changes, code, err := runInCopy(".", tmp, args)
+
+	if err != nil {
+		return false, err
+	}
+
+	writeChanges(os.Stdout, ".", tmp, changes)
+
+	if code != 0 {
+		os.RemoveAll(tmp)
+		os.Exit(code)
+	}
+
+	return len(changes) > 0, nil
+}
+
+// The default "upgrade" subcommand instance.
+var Upgrade = &UpgradeCommand{
+	info: &Info{
+		Line: "%s [options] [target]...",
+		Desc: "Run targets again whose blanks changed.",
+	},
+
+	flags: []*Flag{
+		{Name: "-y, --yes", Desc: "apply changes without asking"},
+		{
+			Name: "--conflict",
+			Desc: "apply policy `p` to existing files",
+			Type: arg.FlagString,
+			Enum: out.Policies,
+		},
+	},
+}
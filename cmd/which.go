@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/makeblank/blank/arg"
+	"github.com/makeblank/blank/blk"
+
+	. "github.com/makeblank/blank/std"
+)
+
+const WhichCommandName = "which"
+
+const whichCommandHelp = `
+Prints the file that is used to run the target, i.e. its
+makefile, script, recipe, executable or template directory,
+then every path that was checked, in order, and the include
+dirs and VPATH that are passed to it.
+`
+
+// The "which" subcommand type.
+type WhichCommand struct {
+	info *Info
+	NoFlags
+}
+
+func (c *WhichCommand) Name() string {
+	return WhichCommandName
+}
+
+func (c *WhichCommand) Info() *Info {
+	return c.info
+}
+
+func (c *WhichCommand) Help() string {
+	return whichCommandHelp
+}
+
+func (c *WhichCommand) Run(args []string) error {
+	var (
+		target string
+		paths  = targetPaths()
+	)
+
+	if target, _ = arg.NextArg(args); Empty(target) {
+		return arg.ArgRequiredError("target")
+	}
+
+	b, cands := blk.Find(target, paths)
+
+	if b != nil {
+		fmt.Println(b.Path)
+	}
+
+	fmt.Println("\nCandidates:")
+
+	for _, c := range cands {
+		status := "missing"
+
+		if c.Found {
+			status = "found"
+		}
+
+		fmt.Printf(FlagLineFormat, status, c.Path)
+	}
+
+	fmt.Println("\nInclude dirs:")
+
+	for _, p := range paths {
+		fmt.Printf("  %s\n", p)
+	}
+
+	fmt.Printf("\n%s:\n  %s\n", vVPATH, vpath(paths))
+
+	if b == nil {
+		return targetNotFoundError(target, cands)
+	}
+
+	return nil
+}
+
+// The default "which" subcommand instance.
+var Which = &WhichCommand{
+	info: &Info{
+		Line: "%s target",
+		Desc: "Show how a target is found.",
+	},
+}
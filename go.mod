@@ -4,6 +4,7 @@ go 1.17
 
 require (
 	github.com/imdario/mergo v0.3.12
+	golang.org/x/term v0.1.0
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 	gotest.tools/v3 v3.0.3
 )
@@ -11,4 +12,5 @@ require (
 require (
 	github.com/google/go-cmp v0.4.0 // indirect
 	github.com/pkg/errors v0.8.1 // indirect
+	golang.org/x/sys v0.0.0-20210615035016-665e8c7367d1 // indirect
 )
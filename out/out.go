@@ -0,0 +1,225 @@
+// Provides a writer of generated files, which detects
+// existing files and applies a conflict policy to them.
+package out
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Policies for files that exist and would change.
+const (
+	PolicySkip      = "skip"      // keep the existing file
+	PolicyOverwrite = "overwrite" // replace the existing file
+	PolicyPrompt    = "prompt"    // ask the user
+	PolicyNew       = "new"       // write a copy next to it, see NewExt
+)
+
+var Policies = []string{
+	PolicySkip,
+	PolicyOverwrite,
+	PolicyPrompt,
+	PolicyNew,
+}
+
+// The extension of copies written by PolicyNew.
+const NewExt = ".blank-new"
+
+// The status of a written file.
+const (
+	Created   = "created"
+	Modified  = "modified"
+	Unchanged = "unchanged"
+	Skipped   = "skipped"
+	Conflict  = "conflict" // A copy was written, see NewExt.
+)
+
+// Is p one of the policies?
+func IsPolicy(p string) bool {
+	for _, policy := range Policies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// A file written by a Writer.
+type File struct {
+	Path   string
+	Status string
+}
+
+// Writes files, and applies a policy to existing files whose
+// content would change. Files with the same content are not
+// written again.
+type Writer struct {
+	Policy string
+
+	// If not nil, used to ask the user about PolicyPrompt.
+	// Otherwise, PolicyPrompt is the same as PolicyNew.
+	In  *bufio.Reader
+	Out io.Writer
+
+	// The files written so far, in order.
+	Files []*File
+}
+
+// Create a writer with the given policy.
+func New(policy string) *Writer {
+	return &Writer{Policy: policy}
+}
+
+// Write data to the file at path p, creating its directory if
+// it does not exist, and record its status. A file that was
+// already created or modified by the writer is overwritten.
+func (w *Writer) WriteFile(p string, data []byte, perm os.FileMode) error {
+	f := w.find(p)
+	policy := w.Policy
+
+	if f != nil && (f.Status == Created || f.Status == Modified) {
+		policy = PolicyOverwrite
+	}
+
+	status, err := w.write(p, data, perm, policy)
+
+	if err != nil {
+		return err
+	}
+
+	if f == nil {
+		w.Files = append(w.Files, &File{Path: p, Status: status})
+	} else if f.Status != Created && status != Unchanged {
+		f.Status = status
+	}
+
+	return nil
+}
+
+// Returns the file at path p, if it was written before.
+func (w *Writer) find(p string) *File {
+	p = filepath.Clean(p)
+
+	for _, f := range w.Files {
+		if filepath.Clean(f.Path) == p {
+			return f
+		}
+	}
+
+	return nil
+}
+
+func (w *Writer) write(p string, data []byte, perm os.FileMode, policy string) (string, error) {
+	prev, err := ioutil.ReadFile(p)
+
+	if os.IsNotExist(err) {
+		if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return "", err
+		}
+		return Created, ioutil.WriteFile(p, data, perm)
+	} else if err != nil {
+		return "", err
+	} else if bytes.Equal(prev, data) {
+		return Unchanged, nil
+	}
+
+	if policy == PolicyPrompt {
+		if policy, err = w.prompt(p); err != nil {
+			return "", err
+		}
+	}
+
+	switch policy {
+	case PolicySkip:
+		return Skipped, nil
+	case PolicyOverwrite:
+		return Modified, ioutil.WriteFile(p, data, perm)
+	case PolicyNew:
+		return Conflict, ioutil.WriteFile(p+NewExt, data, perm)
+	}
+
+	return "", fmt.Errorf("unknown conflict policy: %q", policy)
+}
+
+// Ask the user what to do with the existing file p. Returns
+// the policy to apply to it.
+func (w *Writer) prompt(p string) (string, error) {
+	if w.In == nil {
+		return PolicyNew, nil
+	}
+
+	for {
+		fmt.Fprintf(w.Out, "%s exists. Overwrite? [y]es, [n]o, [c]opy to %s: ", p, NewExt)
+
+		line, err := w.In.ReadString('\n')
+
+		if err == io.EOF && line == "" {
+			fmt.Fprintln(w.Out)
+			return PolicyNew, nil
+		} else if err != nil && err != io.EOF {
+			return "", err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return PolicyOverwrite, nil
+		case "n", "no":
+			return PolicySkip, nil
+		case "c", "copy":
+			return PolicyNew, nil
+		}
+	}
+}
+
+// Returns the files that have the given statuses.
+func (w *Writer) Filter(statuses ...string) (files []*File) {
+	for _, f := range w.Files {
+		for _, s := range statuses {
+			if f.Status == s {
+				files = append(files, f)
+			}
+		}
+	}
+
+	return
+}
+
+// Write a summary of the files that were created, modified,
+// skipped or conflicting, if there are any.
+func (w *Writer) WriteSummary(o io.Writer) {
+	WriteFiles(o, w.Filter(Created, Modified, Skipped, Conflict))
+}
+
+// Write a list of files and their status, if there are any,
+// with statuses padded to the longest one.
+func WriteFiles(o io.Writer, files []*File) {
+	if len(files) == 0 {
+		return
+	}
+
+	width := 0
+
+	for _, f := range files {
+		if len(f.Status) > width {
+			width = len(f.Status)
+		}
+	}
+
+	fmt.Fprintln(o, "\nFiles:")
+
+	for _, f := range files {
+		p := f.Path
+
+		if f.Status == Conflict {
+			p = fmt.Sprintf("%s (see %s)", p, p+NewExt)
+		}
+
+		fmt.Fprintf(o, "  %-*s  %s\n", width, f.Status, p)
+	}
+}
@@ -0,0 +1,81 @@
+package out
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteFile(t *testing.T) {
+	tests := map[string]struct {
+		Policy  string
+		Input   string
+		Status  string
+		Content string
+		New     bool
+	}{
+		"skip":       {Policy: PolicySkip, Status: Skipped, Content: "old"},
+		"overwrite":  {Policy: PolicyOverwrite, Status: Modified, Content: "new"},
+		"new":        {Policy: PolicyNew, Status: Conflict, Content: "old", New: true},
+		"prompt-y":   {Policy: PolicyPrompt, Input: "x\ny\n", Status: Modified, Content: "new"},
+		"prompt-n":   {Policy: PolicyPrompt, Input: "n\n", Status: Skipped, Content: "old"},
+		"prompt-c":   {Policy: PolicyPrompt, Input: "c\n", Status: Conflict, Content: "old", New: true},
+		"prompt-eof": {Policy: PolicyPrompt, Status: Conflict, Content: "old", New: true},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			dir := t.TempDir()
+			p := filepath.Join(dir, "file")
+			assert.NilError(t, ioutil.WriteFile(p, []byte("old"), 0644))
+
+			w := New(tt.Policy)
+
+			if tt.Policy == PolicyPrompt {
+				w.In = bufio.NewReader(strings.NewReader(tt.Input))
+				w.Out = &bytes.Buffer{}
+			}
+
+			assert.NilError(t, w.WriteFile(p, []byte("new"), 0644))
+			assert.DeepEqual(t, w.Files, []*File{{Path: p, Status: tt.Status}})
+
+			b, _ := ioutil.ReadFile(p)
+			assert.Equal(t, string(b), tt.Content)
+
+			_, err := ioutil.ReadFile(p + NewExt)
+			assert.Equal(t, err == nil, tt.New)
+		})
+	}
+}
+
+func TestWriteSummary(t *testing.T) {
+	var b bytes.Buffer
+
+	dir := t.TempDir()
+	w := New(PolicySkip)
+
+	for _, f := range []string{"a", "b"} {
+		assert.NilError(t, ioutil.WriteFile(filepath.Join(dir, f), []byte(f), 0644))
+	}
+
+	assert.NilError(t, w.WriteFile(filepath.Join(dir, "a"), []byte("a"), 0644))
+	assert.NilError(t, w.WriteFile(filepath.Join(dir, "b"), []byte("x"), 0644))
+	assert.NilError(t, w.WriteFile(filepath.Join(dir, "c/d"), []byte("c"), 0644))
+	assert.NilError(t, w.WriteFile(filepath.Join(dir, "c/d"), []byte("d"), 0644))
+
+	w.WriteSummary(&b)
+
+	assert.Equal(t, strings.ReplaceAll(b.String(), dir+"/", ""), `
+Files:
+  skipped  b
+  created  c/d
+`)
+
+	content, _ := ioutil.ReadFile(filepath.Join(dir, "c/d"))
+	assert.Equal(t, string(content), "d")
+}
@@ -0,0 +1,319 @@
+package proj
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/makeblank/blank/snap"
+	"gopkg.in/yaml.v3"
+)
+
+// The name of the journal directory in Dir, which holds a
+// directory for every run that can be undone.
+const JournalDir = "journal"
+
+// The name of the file that describes a run, and of the
+// directory that holds the previous contents of the files
+// it modified or deleted, in a run's journal directory.
+const (
+	journalFile  = "run.yaml"
+	journalFiles = "files"
+)
+
+// A blank run, as recorded in the journal.
+type JournalRun struct {
+	ID      int              `yaml:"id"`
+	Target  string           `yaml:"target"`
+	Time    time.Time        `yaml:"time"`
+	Changes []*JournalChange `yaml:"changes"`
+
+	// The directories that the run created or deleted.
+	Dirs []*JournalChange `yaml:"dirs,omitempty"`
+
+	// The run of the target in the answers file before the
+	// run, if any, and the one that the run recorded, if any.
+	Before *Run `yaml:"before,omitempty"`
+	After  *Run `yaml:"after,omitempty"`
+
+	dir string
+}
+
+// The state of a project that the journal records for a run
+// of a target.
+type JournalState struct {
+	Files snap.Snapshot   // The metadata of the files.
+	Dirs  map[string]bool // The directories.
+	Run   *Run            // The target's run in the answers file.
+}
+
+// A change of a file by a run.
+type JournalChange struct {
+	Path string `yaml:"path"`
+	Kind string `yaml:"kind"` // One of snap.Created, Modified or Deleted.
+
+	// The hash of the file after the run, which is empty if it
+	// was deleted.
+	Hash string `yaml:"hash,omitempty"`
+}
+
+// Returns the path of a project's journal, relative to the
+// project's directory.
+func JournalPath() string {
+	return filepath.Join(Dir, JournalDir)
+}
+
+// Read the journal of the project in directory p, and return
+// its runs, oldest first.
+func ReadJournal(p string) ([]*JournalRun, error) {
+	root := filepath.Join(p, JournalPath())
+	entries, err := ioutil.ReadDir(root)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var runs []*JournalRun
+
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil || !e.IsDir() {
+			continue
+		}
+
+		r := &JournalRun{dir: filepath.Join(root, e.Name())}
+		content, err := ioutil.ReadFile(filepath.Join(r.dir, journalFile))
+
+		if err != nil {
+			return nil, err
+		} else if err = yaml.Unmarshal(content, r); err != nil {
+			return nil, fmt.Errorf("%s: %w", r.dir, err)
+		}
+
+		runs = append(runs, r)
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].ID < runs[j].ID
+	})
+
+	return runs, nil
+}
+
+// Take the state of the project in directory p for a run of
+// target. Files and directories that match a pattern in skip
+// (see snap.Ignored) are left out, and so is Dir, whose
+// answers file is recorded separately.
+func TakeJournalState(p, target string, skip []string) (*JournalState, error) {
+	skip = append([]string{"/" + Dir}, skip...)
+	files, err := snap.Stat(p, skip...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dirs, err := snap.Dirs(p, skip...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	answers, err := ReadAnswers(p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &JournalState{files, dirs, answers.Find(target)}, nil
+}
+
+// Add a run of target that changed the project in directory
+// p from state before to state after to its journal. The
+// files in directory prev must have the contents of the files
+// before the run. Only the files that changed are hashed.
+// Returns nil if the run changed nothing.
+func AddJournalRun(p, target, prev string, before, after *JournalState) (*JournalRun, error) {
+	r := &JournalRun{
+		ID:     1,
+		Target: target,
+		Time:   time.Now(),
+	}
+
+	if after.Run != nil && (before.Run == nil || !after.Run.Time.Equal(before.Run.Time)) {
+		r.Before, r.After = before.Run, after.Run
+	}
+
+	for d := range after.Dirs {
+		if !before.Dirs[d] {
+			r.Dirs = append(r.Dirs, &JournalChange{Path: d, Kind: snap.Created})
+		}
+	}
+
+	for d := range before.Dirs {
+		if !after.Dirs[d] {
+			r.Dirs = append(r.Dirs, &JournalChange{Path: d, Kind: snap.Deleted})
+		}
+	}
+
+	sort.Slice(r.Dirs, func(i, j int) bool {
+		return r.Dirs[i].Path < r.Dirs[j].Path
+	})
+
+	changes := snap.Compare(before.Files, after.Files)
+
+	if len(changes) == 0 && len(r.Dirs) == 0 && r.After == nil {
+		return nil, nil
+	}
+
+	runs, err := ReadJournal(p)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if n := len(runs); n > 0 {
+		r.ID = runs[n-1].ID + 1
+	}
+
+	r.dir = filepath.Join(p, JournalPath(), strconv.Itoa(r.ID))
+
+	for _, c := range changes {
+		jc := &JournalChange{Path: c.Path, Kind: c.Kind}
+
+		if e := after.Files[c.Path]; e != nil && e.Hash != "" {
+			jc.Hash = e.Hash
+		} else if e != nil {
+			if jc.Hash, err = snap.Hash(filepath.Join(p, filepath.FromSlash(c.Path))); err != nil {
+				return nil, err
+			}
+		}
+
+		if c.Kind != snap.Created {
+			src := filepath.Join(prev, filepath.FromSlash(c.Path))
+
+			if err = snap.CopyFile(src, r.filePath(c.Path)); err != nil {
+				return nil, err
+			}
+		}
+
+		r.Changes = append(r.Changes, jc)
+	}
+
+	return r, writeYAML(filepath.Join(r.dir, journalFile), r)
+}
+
+// Returns the path of the previous content of the file at
+// path p, which the run modified or deleted.
+func (r *JournalRun) filePath(p string) string {
+	return filepath.Join(r.dir, journalFiles, filepath.FromSlash(p))
+}
+
+// Returns the changes of the run to files in the project in
+// directory p that were changed again since the run.
+func (r *JournalRun) Conflicts(p string) ([]*JournalChange, error) {
+	var conflicts []*JournalChange
+
+	for _, c := range r.Changes {
+		hash, err := snap.Hash(filepath.Join(p, filepath.FromSlash(c.Path)))
+
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		if hash != c.Hash {
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	return conflicts, nil
+}
+
+// Undo the run's changes to the files in the project in
+// directory p, and remove it from the journal: created files
+// and empty directories are deleted, and modified or deleted
+// files and deleted directories are restored. The target's
+// run in the answers file is restored too, unless it was
+// recorded again since.
+func (r *JournalRun) Undo(p string) error {
+	for _, d := range r.Dirs {
+		if d.Kind == snap.Deleted {
+			if err := os.MkdirAll(filepath.Join(p, filepath.FromSlash(d.Path)), 0755); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, c := range r.Changes {
+		dst := filepath.Join(p, filepath.FromSlash(c.Path))
+
+		var err error
+
+		if c.Kind == snap.Created {
+			if err = os.Remove(dst); err == nil {
+				removeEmptyDirs(p, filepath.Dir(dst))
+			}
+		} else {
+			err = snap.CopyFile(r.filePath(c.Path), dst)
+		}
+
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	// remove created directories, deepest first, if empty
+	for i := len(r.Dirs) - 1; i >= 0; i-- {
+		if d := r.Dirs[i]; d.Kind == snap.Created {
+			os.Remove(filepath.Join(p, filepath.FromSlash(d.Path))) //nolint:errcheck
+		}
+	}
+
+	if err := r.undoAnswers(p); err != nil {
+		return err
+	}
+
+	return os.RemoveAll(r.dir)
+}
+
+// Restore the target's run in the answers file of the project
+// in directory p, if it is still the one that the run recorded.
+func (r *JournalRun) undoAnswers(p string) error {
+	if r.After == nil {
+		return nil
+	}
+
+	answers, err := ReadAnswers(p)
+
+	if err != nil {
+		return err
+	}
+
+	if cur := answers.Find(r.Target); cur == nil || !cur.Time.Equal(r.After.Time) {
+		return nil
+	}
+
+	if r.Before != nil {
+		answers.Add(r.Before)
+	} else {
+		answers.Remove(r.Target)
+	}
+
+	return answers.Write()
+}
+
+// Remove directory dir and its parents up to root, as long as
+// they are empty.
+func removeEmptyDirs(root, dir string) {
+	root = filepath.Clean(root)
+
+	for dir = filepath.Clean(dir); dir != root && dir != "."; dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Provides access to the files that the "blank" program keeps
+// in a project's ".blank" directory.
+package proj
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The name of the project-local directory.
+const Dir = ".blank"
+
+// The name of the answers file in Dir.
+const AnswersFile = "answers.yaml"
+
+// A blank run, as recorded in the answers file.
+type Run struct {
+	Target string            `yaml:"target"`
+	Source string            `yaml:"source"`
+	Hash   string            `yaml:"hash,omitempty"` // Of the blank's content.
+	Params map[string]string `yaml:"params,omitempty"`
+
+	// When the run was made, and by which version of blank.
+	Time    time.Time `yaml:"time,omitempty"`
+	Version string    `yaml:"version,omitempty"`
+}
+
+// The blank runs of a project, i.e. the last run of every
+// target, in the order they were first run.
+type Answers struct {
+	Runs []*Run `yaml:"runs"`
+
+	path string
+}
+
+// Read the answers file of the project in directory p. It is
+// not an error if the file does not exist.
+func ReadAnswers(p string) (*Answers, error) {
+	a := &Answers{path: filepath.Join(p, Dir, AnswersFile)}
+	content, err := ioutil.ReadFile(a.path)
+
+	if os.IsNotExist(err) {
+		return a, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return a, yaml.Unmarshal(content, a)
+}
+
+// Returns the last run of target, or nil if there is none.
+func (a *Answers) Find(target string) *Run {
+	for _, r := range a.Runs {
+		if r.Target == target {
+			return r
+		}
+	}
+	return nil
+}
+
+// Add run r, replacing any previous run of the same target.
+func (a *Answers) Add(r *Run) {
+	for i, prev := range a.Runs {
+		if prev.Target == r.Target {
+			a.Runs[i] = r
+			return
+		}
+	}
+	a.Runs = append(a.Runs, r)
+}
+
+// Remove the run of target, if there is one.
+func (a *Answers) Remove(target string) {
+	for i, r := range a.Runs {
+		if r.Target == target {
+			a.Runs = append(a.Runs[:i], a.Runs[i+1:]...)
+			return
+		}
+	}
+}
+
+// Write the answers file, creating its directory if needed.
+func (a *Answers) Write() error {
+	return writeYAML(a.path, a)
+}
+
+// Write v as YAML to file p, creating its directory if needed.
+func writeYAML(p string, v interface{}) error {
+	var b bytes.Buffer
+
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p, b.Bytes(), 0644)
+}
@@ -0,0 +1,153 @@
+package proj
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/makeblank/blank/snap"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestAnswers(t *testing.T) {
+	dir := t.TempDir()
+	a, err := ReadAnswers(dir)
+
+	assert.NilError(t, err)
+	assert.Equal(t, len(a.Runs), 0)
+
+	a.Add(&Run{Target: "a", Source: "/a.mk"})
+	a.Add(&Run{Target: "b", Source: "/b.mk"})
+	a.Add(&Run{Target: "a", Source: "/a.mk", Params: map[string]string{"x": "1"}})
+
+	assert.NilError(t, a.Write())
+
+	a, err = ReadAnswers(dir)
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, a.Runs, []*Run{
+		{Target: "a", Source: "/a.mk", Params: map[string]string{"x": "1"}},
+		{Target: "b", Source: "/b.mk"},
+	})
+	assert.Assert(t, a.Find("c") == nil)
+}
+
+func TestJournal(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		prev = t.TempDir()
+		skip = "/" + Dir
+	)
+
+	write := func(d, p, s string) {
+		p = filepath.Join(d, p)
+		assert.NilError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		assert.NilError(t, ioutil.WriteFile(p, []byte(s), 0644))
+	}
+
+	addRun := func(r *Run) {
+		a, err := ReadAnswers(dir)
+		assert.NilError(t, err)
+		a.Add(r)
+		assert.NilError(t, a.Write())
+	}
+
+	t0 := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	write(dir, "a", "a")
+	write(dir, "b", "b")
+	assert.NilError(t, os.Mkdir(filepath.Join(dir, "g"), 0755))
+	addRun(&Run{Target: "x", Params: map[string]string{"f": "a"}, Time: t0})
+
+	before, err := TakeJournalState(dir, "x", nil)
+	assert.NilError(t, err)
+	assert.NilError(t, snap.Copy(dir, prev, skip))
+
+	write(dir, "a", "AA")
+	write(dir, "c/d", "d")
+	assert.NilError(t, os.Remove(filepath.Join(dir, "b")))
+	assert.NilError(t, os.MkdirAll(filepath.Join(dir, "e", "f"), 0755))
+	assert.NilError(t, os.Remove(filepath.Join(dir, "g")))
+	addRun(&Run{Target: "x", Params: map[string]string{"f": "b"}, Time: t0.Add(time.Hour)})
+
+	after, err := TakeJournalState(dir, "x", nil)
+	assert.NilError(t, err)
+
+	r, err := AddJournalRun(dir, "x", prev, before, after)
+	assert.NilError(t, err)
+	assert.Equal(t, r.ID, 1)
+
+	r, err = AddJournalRun(dir, "y", prev, after, after)
+	assert.NilError(t, err)
+	assert.Assert(t, r == nil)
+
+	runs, err := ReadJournal(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(runs), 1)
+	assert.Equal(t, runs[0].Target, "x")
+	assert.Equal(t, len(runs[0].Changes), 3)
+	assert.Equal(t, len(runs[0].Dirs), 4)
+
+	write(dir, "a", "AB")
+
+	conflicts, err := runs[0].Conflicts(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(conflicts), 1)
+	assert.Equal(t, conflicts[0].Path, "a")
+
+	write(dir, "a", "AA")
+
+	conflicts, err = runs[0].Conflicts(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(conflicts), 0)
+
+	assert.NilError(t, runs[0].Undo(dir))
+
+	want, err := snap.Take(prev)
+	assert.NilError(t, err)
+
+	now, err := snap.Take(dir, skip)
+	assert.NilError(t, err)
+	assert.Equal(t, len(snap.Compare(want, now)), 0)
+
+	for _, d := range []string{"c", "e"} {
+		_, err = os.Stat(filepath.Join(dir, d))
+		assert.Assert(t, os.IsNotExist(err), d)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "g"))
+	assert.NilError(t, err)
+	assert.Assert(t, info.IsDir())
+
+	a, err := ReadAnswers(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, a.Find("x").Params["f"], "a")
+
+	runs, err = ReadJournal(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(runs), 0)
+}
+
+func TestJournalAnswers(t *testing.T) {
+	dir := t.TempDir()
+	run := &Run{Target: "x", Time: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	none := &JournalState{}
+
+	r, err := AddJournalRun(dir, "x", dir, none, &JournalState{Run: run})
+	assert.NilError(t, err)
+	assert.Assert(t, r != nil)
+
+	a, err := ReadAnswers(dir)
+	assert.NilError(t, err)
+	a.Add(run)
+	assert.NilError(t, a.Write())
+
+	assert.NilError(t, r.Undo(dir))
+
+	a, err = ReadAnswers(dir)
+	assert.NilError(t, err)
+	assert.Assert(t, a.Find("x") == nil)
+}
@@ -0,0 +1,239 @@
+package snap
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// The number of unchanged lines shown around changes.
+const diffContext = 3
+
+// Files whose changed lines, multiplied, are more than this
+// are shown as entirely replaced instead of being compared
+// line by line, which takes as many ints of memory.
+const maxDiffCost = 1 << 20
+
+// An edit of a line: ' ' kept, '-' removed or '+' added.
+type edit struct {
+	op   byte
+	line string
+}
+
+// Returns the unified diff of contents a and b, named as the
+// given files, or the empty string if they are equal. Binary
+// contents are only reported to differ.
+func Diff(aName, bName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+
+	if isBinary(a) || isBinary(b) {
+		return fmt.Sprintf("Binary files %s and %s differ\n", aName, bName)
+	}
+
+	var w strings.Builder
+
+	fmt.Fprintf(&w, "--- %s\n+++ %s\n", aName, bName)
+
+	edits := diffLines(splitLines(a), splitLines(b))
+
+	for _, h := range hunks(edits) {
+		writeHunk(&w, edits, h[0], h[1])
+	}
+
+	return w.String()
+}
+
+func isBinary(b []byte) bool {
+	if len(b) > 8000 {
+		b = b[:8000]
+	}
+	return bytes.IndexByte(b, 0) >= 0
+}
+
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+
+	s := string(b)
+	lines := strings.SplitAfter(s, "\n")
+
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// Returns the edits that turn a into b: their common leading
+// and trailing lines are kept, and the lines between them are
+// compared with lcsEdits.
+func diffLines(a, b []string) []edit {
+	var edits []edit
+
+	p, s := 0, 0
+
+	for p < len(a) && p < len(b) && a[p] == b[p] {
+		p++
+	}
+
+	for s < len(a)-p && s < len(b)-p && a[len(a)-1-s] == b[len(b)-1-s] {
+		s++
+	}
+
+	for _, l := range a[:p] {
+		edits = append(edits, edit{' ', l})
+	}
+
+	edits = append(edits, lcsEdits(a[p:len(a)-s], b[p:len(b)-s])...)
+
+	for _, l := range a[len(a)-s:] {
+		edits = append(edits, edit{' ', l})
+	}
+
+	return edits
+}
+
+// Returns the edits that turn a into b, using the longest
+// common subsequence of their lines.
+func lcsEdits(a, b []string) []edit {
+	var (
+		edits []edit
+		n, m  = len(a), len(b)
+	)
+
+	if n*m > maxDiffCost {
+		for _, l := range a {
+			edits = append(edits, edit{'-', l})
+		}
+		for _, l := range b {
+			edits = append(edits, edit{'+', l})
+		}
+		return edits
+	}
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:]
+	var (
+		lcs   = make([][]int, n+1)
+		table = make([]int, (n+1)*(m+1))
+	)
+
+	for i := range lcs {
+		lcs[i] = table[i*(m+1) : (i+1)*(m+1)]
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+
+	for ; i < n; i++ {
+		edits = append(edits, edit{'-', a[i]})
+	}
+
+	for ; j < m; j++ {
+		edits = append(edits, edit{'+', b[j]})
+	}
+
+	return edits
+}
+
+// Returns the [start, end) ranges of edits shown in hunks:
+// the changes, with diffContext kept lines around them.
+func hunks(edits []edit) (h [][2]int) {
+	for i := 0; i < len(edits); i++ {
+		if edits[i].op == ' ' {
+			continue
+		}
+
+		start := i - diffContext
+
+		if start < 0 {
+			start = 0
+		}
+
+		end := i + 1 + diffContext
+
+		if end > len(edits) {
+			end = len(edits)
+		}
+
+		if n := len(h); n > 0 && start <= h[n-1][1] {
+			h[n-1][1] = end
+		} else {
+			h = append(h, [2]int{start, end})
+		}
+	}
+
+	return
+}
+
+func writeHunk(w *strings.Builder, edits []edit, start, end int) {
+	var (
+		aStart, bStart = 1, 1
+		aLen, bLen     int
+	)
+
+	for _, e := range edits[:start] {
+		if e.op != '+' {
+			aStart++
+		}
+		if e.op != '-' {
+			bStart++
+		}
+	}
+
+	for _, e := range edits[start:end] {
+		if e.op != '+' {
+			aLen++
+		}
+		if e.op != '-' {
+			bLen++
+		}
+	}
+
+	if aLen == 0 {
+		aStart--
+	}
+
+	if bLen == 0 {
+		bStart--
+	}
+
+	fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+
+	for _, e := range edits[start:end] {
+		w.WriteByte(e.op)
+		w.WriteString(e.line)
+
+		if !strings.HasSuffix(e.line, "\n") {
+			w.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
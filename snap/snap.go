@@ -0,0 +1,388 @@
+// Provides snapshots of file trees, to find out which files
+// a blank run created, modified or deleted.
+package snap
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kinds of changes.
+const (
+	Created  = "created"
+	Modified = "modified"
+	Deleted  = "deleted"
+)
+
+// Directories that are never part of a snapshot.
+var Skip = []string{".git"}
+
+// A file in a snapshot.
+type Entry struct {
+	Path    string      `json:"path"` // Relative to the tree's root, with "/".
+	Mode    os.FileMode `json:"mode"`
+	Size    int64       `json:"size"`
+	ModTime time.Time   `json:"modTime"`
+
+	// The hash of the content, or of a symlink's target. It is
+	// empty in snapshots taken by Stat.
+	Hash string `json:"hash"`
+}
+
+// The files in a tree, by path.
+type Snapshot map[string]*Entry
+
+// A change of a file between two snapshots.
+type Change struct {
+	Path string `json:"path"`
+	Kind string `json:"kind"` // One of Created, Modified or Deleted.
+}
+
+// Take a snapshot of the files in dir, skipping the files and
+// directories that match a pattern in Skip or in the given
+// patterns (see Ignored).
+func Take(dir string, skip ...string) (Snapshot, error) {
+	return take(dir, skip, true)
+}
+
+// Take a snapshot of the metadata of the files in dir, like
+// Take, but without reading their contents. Files are then
+// compared by their size, mode and modification time.
+func Stat(dir string, skip ...string) (Snapshot, error) {
+	return take(dir, skip, false)
+}
+
+func take(dir string, skip []string, hash bool) (Snapshot, error) {
+	s := make(Snapshot)
+
+	err := walk(dir, skip, func(rel, p string, info os.FileInfo) error {
+		e := &Entry{
+			Path:    rel,
+			Mode:    info.Mode(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+
+		if hash {
+			var err error
+
+			if e.Hash, err = hashFile(p, info); err != nil {
+				return err
+			}
+		}
+
+		s[rel] = e
+
+		return nil
+	})
+
+	return s, err
+}
+
+// Returns the set of directories in dir, by their paths
+// relative to dir, skipping those that match a pattern in Skip
+// or in the given patterns.
+func Dirs(dir string, skip ...string) (map[string]bool, error) {
+	dirs := make(map[string]bool)
+
+	err := walkAll(dir, skip, func(rel, p string, info os.FileInfo) error {
+		if info.IsDir() {
+			dirs[rel] = true
+		}
+		return nil
+	})
+
+	return dirs, err
+}
+
+// Returns the hash of the content of the file at p, or of its
+// target if it is a symlink, as in snapshots taken by Take.
+func Hash(p string) (string, error) {
+	info, err := os.Lstat(p)
+
+	if err != nil {
+		return "", err
+	}
+
+	return hashFile(p, info)
+}
+
+// Returns the changes from snapshot a to snapshot b, sorted
+// by path.
+func Compare(a, b Snapshot) []*Change {
+	return compare(a, b, (*Entry).differs)
+}
+
+// Returns the changes from snapshot a to snapshot b, like
+// Compare, but files are compared only by their type, content
+// and whether they are executable, as git tracks them, so that
+// other permission bits and times do not matter. Both
+// snapshots must be taken by Take.
+func CompareContent(a, b Snapshot) []*Change {
+	return compare(a, b, (*Entry).differsInContent)
+}
+
+func compare(a, b Snapshot, differs func(e, o *Entry) bool) []*Change {
+	var changes []*Change
+
+	for p, e := range b {
+		if old, ok := a[p]; !ok {
+			changes = append(changes, &Change{p, Created})
+		} else if differs(old, e) {
+			changes = append(changes, &Change{p, Modified})
+		}
+	}
+
+	for p := range a {
+		if _, ok := b[p]; !ok {
+			changes = append(changes, &Change{p, Deleted})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
+
+// Does entry e differ from entry o of the same path? Entries
+// are compared by hash if both have one, and by size and
+// modification time otherwise.
+func (e *Entry) differs(o *Entry) bool {
+	if e.Mode != o.Mode {
+		return true
+	} else if e.Hash != "" && o.Hash != "" {
+		return e.Hash != o.Hash
+	}
+
+	return e.Size != o.Size || !e.ModTime.Equal(o.ModTime)
+}
+
+// Does entry e differ from entry o of the same path in its
+// type, hash or executable bit?
+func (e *Entry) differsInContent(o *Entry) bool {
+	return e.Mode.Type() != o.Mode.Type() ||
+		e.Mode&0100 != o.Mode&0100 ||
+		e.Hash != o.Hash
+}
+
+// Markers of the kinds of changes in WriteTree.
+var markers = map[string]string{
+	Created:  "+",
+	Modified: "~",
+	Deleted:  "-",
+}
+
+// Write the changes as an indented tree of directories and
+// files, with "+" marking created files, "~" modified files
+// and "-" deleted files.
+func WriteTree(w io.Writer, changes []*Change) {
+	var prev []string
+
+	for _, c := range changes {
+		segs := strings.Split(c.Path, "/")
+		dirs := segs[:len(segs)-1]
+		i := 0
+
+		for i < len(dirs) && i < len(prev) && dirs[i] == prev[i] {
+			i++
+		}
+
+		for ; i < len(dirs); i++ {
+			fmt.Fprintf(w, "  %s%s/\n", strings.Repeat("  ", i), dirs[i])
+		}
+
+		fmt.Fprintf(w, "  %s%s %s\n", strings.Repeat("  ", len(dirs)), markers[c.Kind], segs[len(segs)-1])
+
+		prev = dirs
+	}
+}
+
+// Copy the files in src into dst, skipping the files and
+// directories that match a pattern in Skip or in the given
+// patterns. Symlinks are copied as they are.
+func Copy(src, dst string, skip ...string) error {
+	return walk(src, skip, func(rel, p string, info os.FileInfo) error {
+		return CopyFile(p, filepath.Join(dst, filepath.FromSlash(rel)))
+	})
+}
+
+// Copy the file or symlink at src to dst, creating the
+// directory of dst if it does not exist.
+func CopyFile(src, dst string) error {
+	info, err := os.Lstat(src)
+
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	// never write through a symlink at dst
+	if d, err := os.Lstat(dst); err == nil && d.Mode()&os.ModeSymlink != 0 {
+		os.Remove(dst) //nolint:errcheck
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+
+		if err != nil {
+			return err
+		}
+
+		os.Remove(dst) //nolint:errcheck
+
+		return os.Symlink(target, dst)
+	}
+
+	in, err := os.Open(src)
+
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chmod(dst, info.Mode().Perm())
+}
+
+// Call fn for each file or symlink in dir, with its path
+// relative to dir and its path.
+func walk(dir string, skip []string, fn func(rel, p string, info os.FileInfo) error) error {
+	return walkAll(dir, skip, func(rel, p string, info os.FileInfo) error {
+		if info.IsDir() || !info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0 {
+			return nil
+		}
+
+		return fn(rel, p, info)
+	})
+}
+
+// Call fn for each file and directory in dir that is not
+// skipped, with its path relative to dir and its path.
+func walkAll(dir string, skip []string, fn func(rel, p string, info os.FileInfo) error) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || p == dir {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, p)
+
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if isSkipped(rel, info.Name(), skip) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		return fn(rel, p, info)
+	})
+}
+
+func isSkipped(rel, name string, skip []string) bool {
+	return Ignored(rel, name, Skip) || Ignored(rel, name, skip)
+}
+
+// Does any of the patterns match the file or directory with
+// the given slash-separated path, relative to a tree's root,
+// and name? A pattern with a "/", other than a trailing one,
+// matches the path from the root, and other patterns match
+// the name, as in a .gitignore file (see path.Match).
+func Ignored(rel, name string, patterns []string) bool {
+	for _, pat := range patterns {
+		pat = strings.TrimSuffix(pat, "/")
+
+		if strings.Contains(pat, "/") {
+			if ok, _ := path.Match(strings.TrimPrefix(pat, "/"), rel); ok {
+				return true
+			}
+		} else if ok, _ := path.Match(pat, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Read the patterns of an ignore file, such as .gitignore, at
+// path p. Blank lines, comments and negated patterns, which
+// are not supported, are left out. Returns no patterns if the
+// file does not exist.
+func ReadIgnore(p string) ([]string, error) {
+	content, err := ioutil.ReadFile(p)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var patterns []string
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+
+		if line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "!") {
+			patterns = append(patterns, line)
+		}
+	}
+
+	return patterns, nil
+}
+
+func hashFile(p string, info os.FileInfo) (string, error) {
+	h := sha256.New()
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(p)
+
+		if err != nil {
+			return "", err
+		}
+
+		h.Write([]byte(target))
+	} else if info.Mode().IsRegular() {
+		content, err := ioutil.ReadFile(p)
+
+		if err != nil {
+			return "", err
+		}
+
+		h.Write(content)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,200 @@
+package snap
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+
+	write(t, dir, "a.txt", "a")
+	write(t, dir, "b/c.txt", "c")
+	write(t, dir, "b/d.txt", "d")
+	write(t, dir, ".git/HEAD", "ref")
+
+	before, err := Take(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, len(before), 3)
+
+	write(t, dir, "a.txt", "a")
+	write(t, dir, "b/c.txt", "x")
+	write(t, dir, "b/e/f.txt", "f")
+	write(t, dir, ".git/HEAD", "other")
+	assert.NilError(t, os.Remove(filepath.Join(dir, "b/d.txt")))
+
+	after, err := Take(dir)
+	assert.NilError(t, err)
+
+	changes := Compare(before, after)
+
+	assert.DeepEqual(t, changes, []*Change{
+		{"b/c.txt", Modified},
+		{"b/d.txt", Deleted},
+		{"b/e/f.txt", Created},
+	})
+
+	var b bytes.Buffer
+
+	WriteTree(&b, changes)
+
+	assert.Equal(t, b.String(), `  b/
+    ~ c.txt
+    - d.txt
+    e/
+      + f.txt
+`)
+}
+
+func TestCompareContent(t *testing.T) {
+	dir := t.TempDir()
+
+	write(t, dir, "a.txt", "a")
+	write(t, dir, "b.txt", "b")
+	write(t, dir, "c.txt", "c")
+
+	before, err := Take(dir)
+	assert.NilError(t, err)
+
+	assert.NilError(t, os.Chmod(filepath.Join(dir, "a.txt"), 0600))
+	assert.NilError(t, os.Chmod(filepath.Join(dir, "b.txt"), 0755))
+	write(t, dir, "c.txt", "c")
+
+	after, err := Take(dir)
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, CompareContent(before, after), []*Change{
+		{"b.txt", Modified},
+	})
+	assert.Equal(t, len(Compare(before, after)), 2)
+}
+
+func TestStat(t *testing.T) {
+	dir := t.TempDir()
+
+	write(t, dir, "a.txt", "a")
+	write(t, dir, "b.txt", "b")
+
+	before, err := Stat(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, before["a.txt"].Hash, "")
+
+	write(t, dir, "b.txt", "bb")
+
+	after, err := Stat(dir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, Compare(before, after), []*Change{{"b.txt", Modified}})
+}
+
+func TestIgnored(t *testing.T) {
+	dir := t.TempDir()
+
+	write(t, dir, ".gitignore", "# build output\n/bin/\n*.log\n\n!keep.log\nsrc/*.tmp\n")
+
+	patterns, err := ReadIgnore(filepath.Join(dir, ".gitignore"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, patterns, []string{"/bin/", "*.log", "src/*.tmp"})
+
+	tests := map[string]bool{
+		"bin":         true,
+		"src/bin":     false,
+		"a.log":       true,
+		"src/b.log":   true,
+		"src/c.tmp":   true,
+		"src/d/e.tmp": false,
+		"main.go":     false,
+	}
+
+	for rel, want := range tests {
+		assert.Equal(t, Ignored(rel, path.Base(rel), patterns), want, rel)
+	}
+
+	patterns, err = ReadIgnore(filepath.Join(dir, "none"))
+	assert.NilError(t, err)
+	assert.Assert(t, patterns == nil)
+}
+
+func TestCopy(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+
+	write(t, src, "a/b.txt", "b")
+	write(t, src, ".git/HEAD", "ref")
+	assert.NilError(t, os.Chmod(filepath.Join(src, "a/b.txt"), 0755))
+	assert.NilError(t, os.Symlink("a/b.txt", filepath.Join(src, "link")))
+
+	assert.NilError(t, Copy(src, dst))
+
+	a, err := Take(src)
+	assert.NilError(t, err)
+
+	b, err := Take(dst)
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(Compare(a, b)), 0)
+
+	_, err = os.Stat(filepath.Join(dst, ".git"))
+	assert.Assert(t, os.IsNotExist(err))
+}
+
+func TestDiff(t *testing.T) {
+	tests := map[string]struct {
+		A, B string
+		Diff string
+	}{
+		"equal": {"a\n", "a\n", ""},
+		"created": {"", "a\nb\n", `--- a
++++ b
+@@ -0,0 +1,2 @@
++a
++b
+`},
+		"context": {"1\n2\n3\n4\n5\n6\n7\n8\n9\n", "1\n2\n3\n4\nx\n6\n7\n8\n9\n", `--- a
++++ b
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++x
+ 6
+ 7
+ 8
+`},
+		"no newline": {"a\n", "a", `--- a
++++ b
+@@ -1,1 +1,1 @@
+-a
++a
+\ No newline at end of file
+`},
+		"binary": {"a\x00", "b\x00", "Binary files a and b differ\n"},
+		"large": {strings.Repeat("a\n", 5000) + "b\n", strings.Repeat("a\n", 5000) + "c\n", `--- a
++++ b
+@@ -4998,4 +4998,4 @@
+ a
+ a
+ a
+-b
++c
+`},
+	}
+
+	for n, tt := range tests {
+		t.Run(n, func(t *testing.T) {
+			assert.Equal(t, Diff("a", "b", []byte(tt.A), []byte(tt.B)), tt.Diff)
+		})
+	}
+}
+
+func write(t *testing.T, dir, p, content string) {
+	p = filepath.Join(dir, p)
+	assert.NilError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	assert.NilError(t, ioutil.WriteFile(p, []byte(content), 0644))
+}
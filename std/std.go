@@ -8,6 +8,8 @@ import (
 	"os/exec"
 	"os/signal"
 	"strings"
+
+	"golang.org/x/term"
 )
 
 // Run an exec.Cmd with additional mechanics.
@@ -16,11 +18,26 @@ import (
 // command's process, and (2) automatically exit with the
 // command's exit status.
 func ExecCmd(cmd *exec.Cmd) {
+	os.Exit(RunCmd(cmd))
+}
+
+// Run an exec.Cmd, forwarding all signals recieved from this
+// program to the command's process, and return the command's
+// exit status.
+func RunCmd(cmd *exec.Cmd) int {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		WriteError(err)
+		return 1
+	}
+
+	done := make(chan int, 1)
 
 	go func() {
-		err := cmd.Run()
+		err := cmd.Wait()
 		code := cmd.ProcessState.ExitCode()
 
 		// is this condition possible?
@@ -29,15 +46,14 @@ func ExecCmd(cmd *exec.Cmd) {
 			code = 1
 		}
 
-		os.Exit(code)
+		done <- code
 	}()
 
 	for {
-		// keep looping if channel closed or signal failed,
-		// because above goroutine will ultimately os.Exit
-		if s, ok := <-signals; !ok {
-			continue
-		} else {
+		select {
+		case code := <-done:
+			return code
+		case s := <-signals:
 			cmd.Process.Signal(s) //nolint:errcheck
 		}
 	}
@@ -86,6 +102,11 @@ func WriteError(v ...interface{}) {
 	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 }
 
+// Is the file a terminal?
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
 func Head(slice []string) (h string) {
 	if len(slice) > 0 {
 		h = slice[0]
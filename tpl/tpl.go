@@ -0,0 +1,434 @@
+// Provides utilities to render files and file trees with Go
+// text/template and a library of helper functions.
+package tpl
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"unicode"
+
+	"github.com/makeblank/blank/snap"
+)
+
+// The helper functions available in templates. Functions of
+// strings also accept other values, such as numbers, and
+// missing values as empty strings.
+var Funcs = template.FuncMap{
+	"lower":   strFunc(strings.ToLower),
+	"upper":   strFunc(strings.ToUpper),
+	"title":   strFunc(title),
+	"camel":   strFunc(camel),
+	"pascal":  strFunc(pascal),
+	"snake":   strFunc(snake),
+	"kebab":   strFunc(kebab),
+	"trim":    strFunc(strings.TrimSpace),
+	"replace": replace,
+	"split":   split,
+	"join":    join,
+	"default": defaultValue,
+}
+
+// Writes rendered files, e.g. an out.Writer.
+type FileWriter interface {
+	WriteFile(p string, data []byte, perm os.FileMode) error
+}
+
+// Create a template with the helper functions.
+func New(name string) *template.Template {
+	return template.New(name).Funcs(Funcs)
+}
+
+// Render text as a template with the given data. If data is
+// a map, fields that the template refers to and that the map
+// lacks are empty strings, rather than "<no value>".
+func Render(name, text string, data interface{}) (string, error) {
+	var b strings.Builder
+
+	t, err := New(name).Parse(text)
+
+	if err != nil {
+		return "", err
+	}
+
+	if err = t.Execute(&b, withFields(t, data)); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// Render the file tree in src into dst with writer w, and
+// return the paths of the files rendered, relative to dst.
+//
+// The paths and contents of files are rendered with the given
+// data, except for files that match one of the verbatim
+// patterns (see IsVerbatim), which are copied as they are. If
+// a path segment renders as an empty string, the file or
+// directory is skipped. Files and directories that match a
+// pattern in snap.Skip, such as .git, are skipped too.
+func RenderDir(
+	src, dst string,
+	data interface{},
+	verbatim []string,
+	w FileWriter,
+) ([]string, error) {
+	var files []string
+
+	err := filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil || p == src {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		if snap.Ignored(rel, info.Name(), snap.Skip) {
+			return skip(info)
+		}
+
+		raw := IsVerbatim(rel, verbatim)
+		out := rel
+
+		if !raw {
+			if out, err = renderPath(rel, data); err != nil {
+				return err
+			} else if out == "" {
+				return skip(info)
+			}
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(out))
+
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode().Perm()|0700)
+		}
+
+		content, err := ioutil.ReadFile(p)
+
+		if err != nil {
+			return err
+		}
+
+		if !raw {
+			s, err := Render(rel, string(content), data)
+
+			if err != nil {
+				return err
+			}
+
+			content = []byte(s)
+		}
+
+		files = append(files, out)
+
+		return w.WriteFile(target, content, info.Mode().Perm())
+	})
+
+	return files, err
+}
+
+// Is the file at slash-separated path p, or any directory it
+// is in, matched by one of the patterns? Patterns use the
+// syntax of path.Match. Patterns without a "/" are matched
+// against each name in p, and others against the leading
+// segments of p.
+func IsVerbatim(p string, patterns []string) bool {
+	names := strings.Split(p, "/")
+
+	for _, pat := range patterns {
+		pat = strings.Trim(pat, "/")
+
+		if !strings.Contains(pat, "/") {
+			for _, n := range names {
+				if ok, _ := path.Match(pat, n); ok {
+					return true
+				}
+			}
+			continue
+		}
+
+		segs := strings.Count(pat, "/") + 1
+
+		if segs <= len(names) {
+			head := strings.Join(names[:segs], "/")
+
+			if ok, _ := path.Match(pat, head); ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Render each segment of a slash-separated path. Returns an
+// empty path if any segment renders as an empty string.
+func renderPath(p string, data interface{}) (string, error) {
+	segs := strings.Split(p, "/")
+
+	for i, s := range segs {
+		if !strings.Contains(s, "{{") {
+			continue
+		}
+
+		r, err := Render(p, s, data)
+
+		if err != nil {
+			return "", err
+		} else if r = strings.TrimSpace(r); r == "" {
+			return "", nil
+		} else if strings.ContainsAny(r, `/\`) {
+			return "", fmt.Errorf("%s: rendered path segment contains a separator: %q", p, r)
+		} else if r == "." || r == ".." {
+			return "", fmt.Errorf("%s: rendered path segment is not a name: %q", p, r)
+		}
+
+		segs[i] = r
+	}
+
+	return strings.Join(segs, "/"), nil
+}
+
+// Returns data with the fields that template t refers to and
+// that it lacks as empty strings, if data is a map of strings
+// or of values.
+func withFields(t *template.Template, data interface{}) interface{} {
+	m := make(map[string]interface{})
+
+	switch d := data.(type) {
+	case map[string]string:
+		for k, v := range d {
+			m[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range d {
+			m[k] = v
+		}
+	default:
+		return data
+	}
+
+	for _, f := range fields(t.Root, nil) {
+		if _, ok := m[f]; !ok {
+			m[f] = ""
+		}
+	}
+
+	return m
+}
+
+// Append the first names of the fields, e.g. "a" of .a.b, in
+// the parse tree of node n to names.
+func fields(n parse.Node, names []string) []string {
+	switch n := n.(type) {
+	case *parse.ListNode:
+		if n != nil {
+			for _, c := range n.Nodes {
+				names = fields(c, names)
+			}
+		}
+	case *parse.ActionNode:
+		names = fields(n.Pipe, names)
+	case *parse.PipeNode:
+		if n != nil {
+			for _, c := range n.Cmds {
+				names = fields(c, names)
+			}
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			names = fields(a, names)
+		}
+	case *parse.FieldNode:
+		names = append(names, n.Ident[0])
+	case *parse.ChainNode:
+		names = fields(n.Node, names)
+	case *parse.IfNode:
+		names = fields(&n.BranchNode, names)
+	case *parse.RangeNode:
+		names = fields(&n.BranchNode, names)
+	case *parse.WithNode:
+		names = fields(&n.BranchNode, names)
+	case *parse.BranchNode:
+		names = fields(n.Pipe, names)
+		names = fields(n.List, names)
+		names = fields(n.ElseList, names)
+	case *parse.TemplateNode:
+		names = fields(n.Pipe, names)
+	}
+
+	return names
+}
+
+func skip(info os.FileInfo) error {
+	if info.IsDir() {
+		return filepath.SkipDir
+	}
+	return nil
+}
+
+// Returns v as a string. Nil is the empty string.
+func str(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func strFunc(fn func(string) string) func(interface{}) string {
+	return func(v interface{}) string {
+		return fn(str(v))
+	}
+}
+
+// Split s into words at non-alphanumeric characters and at
+// changes from lower to upper case, e.g. "fooBar-baz" into
+// "foo", "Bar" and "baz".
+func words(s string) []string {
+	var (
+		ws   []string
+		w    []rune
+		prev rune
+	)
+
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			if len(w) > 0 {
+				ws = append(ws, string(w))
+				w = nil
+			}
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			ws = append(ws, string(w))
+			w = []rune{r}
+		default:
+			w = append(w, r)
+		}
+
+		prev = r
+	}
+
+	if len(w) > 0 {
+		ws = append(ws, string(w))
+	}
+
+	return ws
+}
+
+// Returns s with its first letter in upper case.
+func capitalize(s string) string {
+	for i, r := range s {
+		return s[:i] + string(unicode.ToUpper(r)) + s[i+len(string(r)):]
+	}
+	return s
+}
+
+// "foo bar" => "Foo Bar"
+func title(s string) string {
+	ws := strings.Fields(s)
+
+	for i, w := range ws {
+		ws[i] = capitalize(w)
+	}
+
+	return strings.Join(ws, " ")
+}
+
+// "foo-bar baz" => "fooBarBaz"
+func camel(s string) string {
+	ws := words(s)
+
+	for i, w := range ws {
+		if w = strings.ToLower(w); i > 0 {
+			w = capitalize(w)
+		}
+		ws[i] = w
+	}
+
+	return strings.Join(ws, "")
+}
+
+// "foo-bar baz" => "FooBarBaz"
+func pascal(s string) string {
+	return capitalize(camel(s))
+}
+
+// "fooBar baz" => "foo_bar_baz"
+func snake(s string) string {
+	return strings.ToLower(strings.Join(words(s), "_"))
+}
+
+// "fooBar baz" => "foo-bar-baz"
+func kebab(s string) string {
+	return strings.ToLower(strings.Join(words(s), "-"))
+}
+
+// {{ .name | replace "-" "_" }}
+func replace(old, new string, v interface{}) string {
+	return strings.ReplaceAll(str(v), old, new)
+}
+
+// {{ .list | split "," }}
+func split(sep string, v interface{}) []string {
+	if s := str(v); s != "" {
+		return strings.Split(s, sep)
+	}
+	return nil
+}
+
+// {{ .list | join ", " }}, where list is an array, or a
+// string that is returned as it is.
+func join(sep string, v interface{}) (string, error) {
+	if s, ok := v.(string); ok || v == nil {
+		return s, nil
+	}
+
+	r := reflect.ValueOf(v)
+
+	if k := r.Kind(); k != reflect.Slice && k != reflect.Array {
+		return "", fmt.Errorf("join: not an array: %v", v)
+	}
+
+	s := make([]string, r.Len())
+
+	for i := range s {
+		s[i] = fmt.Sprint(r.Index(i).Interface())
+	}
+
+	return strings.Join(s, sep), nil
+}
+
+// {{ .name | default "value" }} returns the default value if
+// name is missing or empty.
+func defaultValue(d, v interface{}) interface{} {
+	if v == nil {
+		return d
+	}
+
+	r := reflect.ValueOf(v)
+
+	switch r.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		if r.Len() == 0 {
+			return d
+		}
+	case reflect.Bool:
+		if !r.Bool() {
+			return d
+		}
+	}
+
+	return v
+}
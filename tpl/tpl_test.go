@@ -0,0 +1,143 @@
+package tpl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/makeblank/blank/out"
+	"gotest.tools/v3/assert"
+)
+
+func TestFuncs(t *testing.T) {
+	data := map[string]interface{}{
+		"name":  "fooBar baz",
+		"list":  []interface{}{"a", "b"},
+		"empty": "",
+	}
+
+	tests := map[string]string{
+		`{{ .name | lower }}`:                "foobar baz",
+		`{{ .name | upper }}`:                "FOOBAR BAZ",
+		`{{ .name | title }}`:                "FooBar Baz",
+		`{{ .name | camel }}`:                "fooBarBaz",
+		`{{ .name | pascal }}`:               "FooBarBaz",
+		`{{ .name | snake }}`:                "foo_bar_baz",
+		`{{ .name | kebab }}`:                "foo-bar-baz",
+		`{{ "HTTPServer2Go" | kebab }}`:      "httpserver2-go",
+		`{{ " x " | trim }}`:                 "x",
+		`{{ .name | replace " " "_" }}`:      "fooBar_baz",
+		`{{ .list | join ", " }}`:            "a, b",
+		`{{ "a,b" | split "," | join "+" }}`: "a+b",
+		`{{ .empty | default "d" }}`:         "d",
+		`{{ .none | default "d" }}`:          "d",
+		`{{ .name | default "d" }}`:          "fooBar baz",
+		`{{ .none | pascal }}`:               "",
+		`{{ .none }}`:                        "",
+		`{{ if .none }}x{{ end }}`:           "",
+		`{{ 8080 | upper }}`:                 "8080",
+	}
+
+	for text, want := range tests {
+		t.Run(text, func(t *testing.T) {
+			got, err := Render("test", text, data)
+			assert.NilError(t, err)
+			assert.Equal(t, got, want)
+		})
+	}
+}
+
+func TestIsVerbatim(t *testing.T) {
+	patterns := []string{"*.css", "vendor/", "docs/*.md"}
+
+	tests := map[string]bool{
+		"style.css":         true,
+		"assets/style.css":  true,
+		"vendor/lib/a.go":   true,
+		"src/vendor/a.go":   true,
+		"docs/index.md":     true,
+		"docs/api/index.md": false,
+		"src/docs/index.md": false,
+		"README.md":         false,
+		"main.go":           false,
+	}
+
+	for p, want := range tests {
+		assert.Equal(t, IsVerbatim(p, patterns), want, p)
+	}
+}
+
+func TestRenderDir(t *testing.T) {
+	dst := t.TempDir()
+	data := map[string]interface{}{"name": "billing", "ci": false}
+
+	files, err := RenderDir("test/src", dst, data, []string{"assets/"}, out.New(out.PolicyOverwrite))
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, files, []string{
+		".gitignore",
+		"README.md",
+		"assets/style.css",
+		"billing/main.txt",
+	})
+	assert.DeepEqual(t, readTree(t, dst), readTree(t, "test/res"))
+
+	// a git repository is not part of the template
+	src, dst := t.TempDir(), t.TempDir()
+	assert.NilError(t, os.Mkdir(filepath.Join(src, ".git"), 0755))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(src, ".git", "HEAD"), nil, 0644))
+	assert.NilError(t, ioutil.WriteFile(filepath.Join(src, ".env"), nil, 0644))
+
+	files, err = RenderDir(src, dst, data, nil, out.New(out.PolicyOverwrite))
+
+	assert.NilError(t, err)
+	assert.DeepEqual(t, files, []string{".env"})
+}
+
+func TestRenderPath(t *testing.T) {
+	tests := []struct {
+		path, res, err string
+	}{
+		{path: "{{ .a }}/b.txt", res: "x/b.txt"},
+		{path: "a/{{ .empty }}/b.txt", res: ""},
+		{path: "{{ .sep }}/b.txt", err: `{{ .sep }}/b.txt: rendered path segment contains a separator: "x/y"`},
+		{path: "{{ .dot }}/b.txt", err: `{{ .dot }}/b.txt: rendered path segment is not a name: "."`},
+		{path: "{{ .up }}/b.txt", err: `{{ .up }}/b.txt: rendered path segment is not a name: ".."`},
+	}
+
+	data := map[string]string{"a": "x", "empty": "", "sep": "x/y", "dot": ".", "up": ".."}
+
+	for _, test := range tests {
+		res, err := renderPath(test.path, data)
+
+		if test.err != "" {
+			assert.Error(t, err, test.err, test.path)
+			continue
+		}
+
+		assert.NilError(t, err, test.path)
+		assert.Equal(t, res, test.res, test.path)
+	}
+}
+
+// Returns the contents of the files in dir by their paths.
+func readTree(t *testing.T, dir string) map[string]string {
+	tree := make(map[string]string)
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		b, err := ioutil.ReadFile(p)
+		rel, _ := filepath.Rel(dir, p)
+		tree[filepath.ToSlash(rel)] = string(b)
+
+		return err
+	})
+
+	assert.NilError(t, err)
+
+	return tree
+}